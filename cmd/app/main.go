@@ -30,7 +30,27 @@ func main() {
 	}
 	logger.Info("successfully connected to database")
 	repositories := repository.New(db, logger)
-	services := service.New(repositories, cfg, logger)
+	services, err := service.New(repositories, cfg, logger)
+	if err != nil {
+		logger.Fatal("failed to initialize services", zap.Error(err))
+	}
+	if n, err := services.PersonService.BackfillNormalizedNames(ctx); err != nil {
+		logger.Error("failed to backfill normalized names", zap.Error(err))
+	} else if n > 0 {
+		logger.Info("backfilled normalized names", zap.Int("count", n))
+	}
+	if services.EnrichmentPool != nil {
+		services.EnrichmentPool.Start()
+	}
+	if services.JobQueue != nil {
+		services.JobQueue.Start()
+	}
+	if services.RefreshScheduler != nil {
+		services.RefreshScheduler.Start()
+	}
+	if services.UsageTracker != nil {
+		services.UsageTracker.Start()
+	}
 	handlers := handler.New(services, logger)
 	mux := handler.Router(*handlers)
 	httpServer := &http.Server{
@@ -56,6 +76,27 @@ func main() {
 	if err := httpServer.Shutdown(shutdownCtx); err != nil {
 		logger.Fatal("HTTP server shutdown error", zap.Error(err))
 	}
+	if services.RefreshScheduler != nil {
+		if err := services.RefreshScheduler.Shutdown(shutdownCtx); err != nil {
+			logger.Error("stale re-enrichment did not stop in time", zap.Error(err))
+		}
+	}
+	if services.EnrichmentPool != nil {
+		if err := services.EnrichmentPool.Shutdown(shutdownCtx); err != nil {
+			logger.Error("enrichment workers did not drain in time", zap.Error(err))
+		}
+	}
+	if services.JobQueue != nil {
+		if err := services.JobQueue.Shutdown(shutdownCtx); err != nil {
+			logger.Error("enrichment queue workers did not stop in time", zap.Error(err))
+		}
+	}
+	// Last, so that requests made while draining are counted too.
+	if services.UsageTracker != nil {
+		if err := services.UsageTracker.Shutdown(shutdownCtx); err != nil {
+			logger.Error("failed to save provider usage", zap.Error(err))
+		}
+	}
 
 	logger.Info("Server stopped gracefully")
 }
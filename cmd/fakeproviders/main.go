@@ -0,0 +1,61 @@
+// Command fakeproviders serves fake agify, genderize and nationalize APIs for
+// local development, see package fakeproviders.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/adal4ik/people-enrichment-service/internal/fakeproviders"
+)
+
+func main() {
+	var (
+		addr        = flag.String("addr", ":8090", "listen address")
+		answersPath = flag.String("answers", "", "JSON file of fixed answers by name")
+		latency     = flag.Duration("latency", 0, "delay added to every request")
+		errorRate   = flag.Float64("error-rate", 0, "fraction of requests to fail, 0 to 1")
+		errorStatus = flag.Int("error-status", http.StatusInternalServerError, "status of injected errors")
+		rateLimit   = flag.Int("rate-limit", 0, "daily request quota per provider, 0 for none")
+		seed        = flag.Uint64("seed", 1, "seed of the error injection")
+	)
+	flag.Parse()
+
+	cfg := fakeproviders.Config{
+		Latency:     *latency,
+		ErrorRate:   *errorRate,
+		ErrorStatus: *errorStatus,
+		RateLimit:   *rateLimit,
+		Seed:        *seed,
+	}
+	if *answersPath != "" {
+		answers, err := fakeproviders.LoadAnswers(*answersPath)
+		if err != nil {
+			log.Fatalf("failed to load answers: %v", err)
+		}
+		cfg.Answers = answers
+	}
+
+	server := &http.Server{Addr: *addr, Handler: fakeproviders.New(cfg)}
+	go func() {
+		log.Printf("fake providers listening on %s (/agify, /genderize, /nationalize)", *addr)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("ListenAndServe error: %v", err)
+		}
+	}()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	<-ctx.Done()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Fatalf("shutdown error: %v", err)
+	}
+}
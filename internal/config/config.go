@@ -3,6 +3,9 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -18,6 +21,100 @@ type Config struct {
 	APIAgeURL    string
 	APINationURL string
 
+	// Enrichment providers per attribute, tried in order. An empty list disables the attribute.
+	AgeProviders    []string
+	GenderProviders []string
+	NationProviders []string
+	// How the answers of several providers are combined per attribute:
+	// "first-success", "highest-confidence" or "weighted-vote".
+	AgeStrategy    string
+	GenderStrategy string
+	NationStrategy string
+	// ProviderWeights are the weighted-vote weights by provider; unlisted ones weigh 1.
+	ProviderWeights map[string]float64
+	// Minimum probability and sample count for accepting an enriched value; weaker
+	// answers are stored as unknown and flagged low-confidence. Zero disables a check.
+	AgeMinSampleCount    int
+	GenderMinProbability float64
+	GenderMinSampleCount int
+	NationMinProbability float64
+	NationMinSampleCount int
+	// LocalDatasetPath is the CSV or JSON name-statistics file of the "local" provider.
+	LocalDatasetPath string
+	// GenderRulesTieBreak lets patronymic and surname rules override remote gender
+	// answers less probable than this; zero disables it.
+	GenderRulesTieBreak float64
+	// NameTransliteration is the standard used to romanize Cyrillic first names
+	// before lookups: "icao", "gost" or "none".
+	NameTransliteration string
+	// EnrichTimeout bounds all provider lookups made for a single person.
+	EnrichTimeout time.Duration
+	// EnrichMode is "sync" (enrich before saving), "async" (save, then enrich in
+	// background workers) or "queue" (async through the durable enrichment_jobs table).
+	EnrichMode      string
+	EnrichWorkers   int
+	EnrichQueueSize int
+	// Durable queue: failed jobs are retried with exponential backoff up to the
+	// maximum attempts, and a claimed job is reclaimed by another worker once
+	// its visibility timeout expires.
+	EnrichJobMaxAttempts       int
+	EnrichJobBackoff           time.Duration
+	EnrichJobMaxBackoff        time.Duration
+	EnrichJobVisibilityTimeout time.Duration
+	EnrichJobPollInterval      time.Duration
+	// EnrichPolicy is "strict" (reject on any failed attribute) or "best-effort" (save what succeeded).
+	EnrichPolicy string
+	// EnrichLocalize looks nationality up first and localizes age and gender to its best guess.
+	EnrichLocalize bool
+	// EnrichCoalesce lets concurrent lookups of the same name share one provider call.
+	EnrichCoalesce bool
+
+	// Outbound HTTP client shared by the remote providers.
+	ProviderHTTPTimeout         time.Duration
+	ProviderMaxIdleConns        int
+	ProviderMaxIdleConnsPerHost int
+	// ProviderProxyURL overrides the HTTP(S)_PROXY environment variables.
+	ProviderProxyURL string
+	// ProviderCABundle is a PEM file of extra trusted certificate authorities.
+	ProviderCABundle  string
+	ProviderUserAgent string
+	// ProviderAPIKey is sent as the ProviderAPIKeyParam query parameter when set.
+	// The per-provider keys take precedence over it.
+	ProviderAPIKey      string
+	ProviderAPIKeyParam string
+	AgifyAPIKey         string
+	GenderizeAPIKey     string
+	NationalizeAPIKey   string
+	// UsageFlushInterval is how often request counters are written to Postgres;
+	// zero disables usage tracking.
+	UsageFlushInterval time.Duration
+
+	// Retries of transient provider failures.
+	RetryMaxAttempts int
+	RetryBaseDelay   time.Duration
+	RetryMaxDelay    time.Duration
+
+	// Per-provider circuit breaker; a zero threshold disables it.
+	BreakerFailureThreshold int
+	BreakerCooldown         time.Duration
+
+	// Client-side limiting driven by provider X-Rate-Limit-* headers.
+	RateLimitEnabled bool
+	RateLimitReserve int
+	RateLimitMaxWait time.Duration
+
+	// In-memory cache of provider answers; a zero size or TTL disables it.
+	CacheMaxSize int
+	CacheTTL     time.Duration
+	// Staleness limit of the shared Postgres name cache; zero disables it.
+	PersistentCacheMaxAge time.Duration
+
+	// Periodic re-enrichment of stale persons; a zero interval disables it.
+	RefreshInterval   time.Duration
+	RefreshMaxAge     time.Duration
+	RefreshBatchSize  int
+	RefreshBatchDelay time.Duration
+
 	LogLevel string
 }
 
@@ -28,15 +125,69 @@ func LoadConfig() Config {
 	}
 
 	return Config{
-		DBHost:       getEnv("DB_HOST", "localhost"),
-		DBPort:       getEnv("DB_PORT", "5432"),
-		DBUser:       getEnv("DB_USER", "postgres"),
-		DBPassword:   getEnv("DB_PASSWORD", ""),
-		DBName:       getEnv("DB_NAME", "peopledb"),
-		APIGenderURL: getEnv("API_GENDER_URL", "https://api.genderapi.io"),
-		APIAgeURL:    getEnv("API_AGE_URL", "https://api.agify.io"),
-		APINationURL: getEnv("API_NATION_URL", "https://api.nationalize.io"),
-		LogLevel:     getEnv("LOG_LEVEL", "debug"),
+		DBHost:                      getEnv("DB_HOST", "localhost"),
+		DBPort:                      getEnv("DB_PORT", "5432"),
+		DBUser:                      getEnv("DB_USER", "postgres"),
+		DBPassword:                  getEnv("DB_PASSWORD", ""),
+		DBName:                      getEnv("DB_NAME", "peopledb"),
+		APIGenderURL:                getEnv("API_GENDER_URL", "https://api.genderapi.io"),
+		APIAgeURL:                   getEnv("API_AGE_URL", "https://api.agify.io"),
+		APINationURL:                getEnv("API_NATION_URL", "https://api.nationalize.io"),
+		AgeProviders:                getEnvList("ENRICH_AGE_PROVIDERS", "agify"),
+		GenderProviders:             getEnvList("ENRICH_GENDER_PROVIDERS", "genderize"),
+		NationProviders:             getEnvList("ENRICH_NATION_PROVIDERS", "nationalize"),
+		AgeStrategy:                 getEnv("ENRICH_AGE_STRATEGY", "first-success"),
+		GenderStrategy:              getEnv("ENRICH_GENDER_STRATEGY", "first-success"),
+		NationStrategy:              getEnv("ENRICH_NATION_STRATEGY", "first-success"),
+		ProviderWeights:             getEnvWeights("PROVIDER_WEIGHTS"),
+		AgeMinSampleCount:           getEnvInt("AGE_MIN_SAMPLE_COUNT", 0),
+		GenderMinProbability:        getEnvFloat("GENDER_MIN_PROBABILITY", 0),
+		GenderMinSampleCount:        getEnvInt("GENDER_MIN_SAMPLE_COUNT", 0),
+		NationMinProbability:        getEnvFloat("NATION_MIN_PROBABILITY", 0),
+		NationMinSampleCount:        getEnvInt("NATION_MIN_SAMPLE_COUNT", 0),
+		LocalDatasetPath:            getEnv("LOCAL_DATASET_PATH", ""),
+		GenderRulesTieBreak:         getEnvFloat("GENDER_RULES_TIEBREAK_BELOW", 0),
+		NameTransliteration:         getEnv("NAME_TRANSLITERATION", "icao"),
+		EnrichTimeout:               getEnvDuration("ENRICH_TIMEOUT", 10*time.Second),
+		EnrichMode:                  getEnv("ENRICH_MODE", "sync"),
+		EnrichPolicy:                getEnv("ENRICH_POLICY", "strict"),
+		EnrichLocalize:              getEnvBool("ENRICH_LOCALIZE", false),
+		EnrichCoalesce:              getEnvBool("ENRICH_COALESCE", true),
+		EnrichWorkers:               getEnvInt("ENRICH_WORKERS", 4),
+		EnrichQueueSize:             getEnvInt("ENRICH_QUEUE_SIZE", 1000),
+		EnrichJobMaxAttempts:        getEnvInt("ENRICH_JOB_MAX_ATTEMPTS", 5),
+		EnrichJobBackoff:            getEnvDuration("ENRICH_JOB_BACKOFF", 10*time.Second),
+		EnrichJobMaxBackoff:         getEnvDuration("ENRICH_JOB_MAX_BACKOFF", 10*time.Minute),
+		EnrichJobVisibilityTimeout:  getEnvDuration("ENRICH_JOB_VISIBILITY_TIMEOUT", 2*time.Minute),
+		EnrichJobPollInterval:       getEnvDuration("ENRICH_JOB_POLL_INTERVAL", time.Second),
+		ProviderHTTPTimeout:         getEnvDuration("PROVIDER_HTTP_TIMEOUT", 5*time.Second),
+		ProviderMaxIdleConns:        getEnvInt("PROVIDER_MAX_IDLE_CONNS", 100),
+		ProviderMaxIdleConnsPerHost: getEnvInt("PROVIDER_MAX_IDLE_CONNS_PER_HOST", 10),
+		ProviderProxyURL:            getEnv("PROVIDER_PROXY_URL", ""),
+		ProviderCABundle:            getEnv("PROVIDER_CA_BUNDLE", ""),
+		ProviderUserAgent:           getEnv("PROVIDER_USER_AGENT", "people-enrichment-service"),
+		ProviderAPIKey:              getEnv("PROVIDER_API_KEY", ""),
+		ProviderAPIKeyParam:         getEnv("PROVIDER_API_KEY_PARAM", "apikey"),
+		AgifyAPIKey:                 getEnv("AGIFY_API_KEY", ""),
+		GenderizeAPIKey:             getEnv("GENDERIZE_API_KEY", ""),
+		NationalizeAPIKey:           getEnv("NATIONALIZE_API_KEY", ""),
+		UsageFlushInterval:          getEnvDuration("USAGE_FLUSH_INTERVAL", 30*time.Second),
+		RetryMaxAttempts:            getEnvInt("RETRY_MAX_ATTEMPTS", 3),
+		RetryBaseDelay:              getEnvDuration("RETRY_BASE_DELAY", 200*time.Millisecond),
+		RetryMaxDelay:               getEnvDuration("RETRY_MAX_DELAY", 5*time.Second),
+		BreakerFailureThreshold:     getEnvInt("BREAKER_FAILURE_THRESHOLD", 5),
+		BreakerCooldown:             getEnvDuration("BREAKER_COOLDOWN", 30*time.Second),
+		RateLimitEnabled:            getEnvBool("RATE_LIMIT_ENABLED", true),
+		RateLimitReserve:            getEnvInt("RATE_LIMIT_RESERVE", 0),
+		RateLimitMaxWait:            getEnvDuration("RATE_LIMIT_MAX_WAIT", 2*time.Second),
+		CacheMaxSize:                getEnvInt("CACHE_MAX_SIZE", 10000),
+		CacheTTL:                    getEnvDuration("CACHE_TTL", 24*time.Hour),
+		PersistentCacheMaxAge:       getEnvDuration("PERSISTENT_CACHE_MAX_AGE", 30*24*time.Hour),
+		RefreshInterval:             getEnvDuration("REFRESH_INTERVAL", time.Hour),
+		RefreshMaxAge:               getEnvDuration("REFRESH_MAX_AGE", 90*24*time.Hour),
+		RefreshBatchSize:            getEnvInt("REFRESH_BATCH_SIZE", 50),
+		RefreshBatchDelay:           getEnvDuration("REFRESH_BATCH_DELAY", 5*time.Second),
+		LogLevel:                    getEnv("LOG_LEVEL", "debug"),
 	}
 }
 
@@ -46,3 +197,83 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+// getEnvList reads a comma-separated list, lower-cased and without empty items.
+func getEnvList(key, fallback string) []string {
+	var list []string
+	for _, item := range strings.Split(getEnv(key, fallback), ",") {
+		item = strings.ToLower(strings.TrimSpace(item))
+		if item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// getEnvWeights reads a comma-separated list of name=weight pairs such as
+// "genderize=2,local=0.5". Invalid pairs are skipped with a warning.
+func getEnvWeights(key string) map[string]float64 {
+	weights := make(map[string]float64)
+	for _, item := range getEnvList(key, "") {
+		name, value, ok := strings.Cut(item, "=")
+		w, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if !ok || err != nil || w < 0 {
+			log.Printf("Warning: invalid weight %q in %s, ignoring it", item, key)
+			continue
+		}
+		weights[strings.TrimSpace(name)] = w
+	}
+	return weights
+}
+
+func getEnvInt(key string, fallback int) int {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Warning: invalid integer %q for %s, using %d", value, key, fallback)
+		return fallback
+	}
+	return n
+}
+
+func getEnvFloat(key string, fallback float64) float64 {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return fallback
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Printf("Warning: invalid number %q for %s, using %g", value, key, fallback)
+		return fallback
+	}
+	return f
+}
+
+func getEnvBool(key string, fallback bool) bool {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return fallback
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("Warning: invalid boolean %q for %s, using %t", value, key, fallback)
+		return fallback
+	}
+	return b
+}
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Warning: invalid duration %q for %s, using %s", value, key, fallback)
+		return fallback
+	}
+	return d
+}
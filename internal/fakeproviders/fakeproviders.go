@@ -0,0 +1,285 @@
+// Package fakeproviders serves agify, genderize and nationalize compatible
+// APIs with deterministic answers, for local development and tests.
+//
+// The APIs are mounted under /agify, /genderize and /nationalize, so the
+// service is pointed at them with API_AGE_URL=http://host:port/agify and so on.
+// Both ?name=x and batch ?name[]=x&name[]=y requests are understood.
+package fakeproviders
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Provider names, also the path each API is served under.
+const (
+	Agify       = "agify"
+	Genderize   = "genderize"
+	Nationalize = "nationalize"
+)
+
+// Country is one entry of a nationality answer.
+type Country struct {
+	CountryID   string  `json:"country_id"`
+	Probability float64 `json:"probability"`
+}
+
+// Answer overrides the generated answer for a name. Zero fields keep the
+// generated value; Unknown answers with nulls as the real APIs do for names
+// they have never seen, and a non-zero Status fails the request.
+type Answer struct {
+	Age         *int      `json:"age,omitempty"`
+	Gender      string    `json:"gender,omitempty"`
+	Probability *float64  `json:"probability,omitempty"`
+	Countries   []Country `json:"countries,omitempty"`
+	Count       *int      `json:"count,omitempty"`
+	Unknown     bool      `json:"unknown,omitempty"`
+	Status      int       `json:"status,omitempty"`
+}
+
+// Config controls the behaviour of the fake APIs.
+type Config struct {
+	// Answers maps a lower-cased name to its fixed answer.
+	Answers map[string]Answer
+	// Latency is added to every request.
+	Latency time.Duration
+	// ErrorRate is the fraction of requests, 0 to 1, failed with ErrorStatus.
+	ErrorRate   float64
+	ErrorStatus int
+	// Seed makes the injected errors reproducible.
+	Seed uint64
+	// RateLimit, when positive, is the daily quota reported in the
+	// X-Rate-Limit-* headers; requests over it get 429. Like the real APIs,
+	// the quota resets at midnight UTC.
+	RateLimit int
+}
+
+// LoadAnswers reads a JSON object of name to Answer.
+func LoadAnswers(path string) (map[string]Answer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read answers: %w", err)
+	}
+	var answers map[string]Answer
+	if err := json.Unmarshal(data, &answers); err != nil {
+		return nil, fmt.Errorf("failed to decode answers: %w", err)
+	}
+	return answers, nil
+}
+
+// Server is an http.Handler serving the three fake APIs.
+type Server struct {
+	cfg Config
+	mux *http.ServeMux
+
+	mu       sync.Mutex
+	rand     *rand.Rand
+	requests map[string]int
+	// used counts the requests of day, the current UTC day, per provider.
+	used map[string]int
+	day  time.Time
+	now  func() time.Time
+}
+
+func New(cfg Config) *Server {
+	if cfg.ErrorStatus == 0 {
+		cfg.ErrorStatus = http.StatusInternalServerError
+	}
+	answers := make(map[string]Answer, len(cfg.Answers))
+	for name, answer := range cfg.Answers {
+		answers[normalize(name)] = answer
+	}
+	cfg.Answers = answers
+
+	s := &Server{
+		cfg:      cfg,
+		mux:      http.NewServeMux(),
+		rand:     rand.New(rand.NewPCG(cfg.Seed, cfg.Seed)),
+		requests: make(map[string]int),
+		used:     make(map[string]int),
+		now:      time.Now,
+	}
+	for _, provider := range []string{Agify, Genderize, Nationalize} {
+		s.mux.HandleFunc("/"+provider, s.handle(provider))
+		s.mux.HandleFunc("/"+provider+"/", s.handle(provider))
+	}
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Requests returns the number of requests provider has received.
+func (s *Server) Requests(provider string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[provider]
+}
+
+func (s *Server) handle(provider string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		count, fail := s.admit(provider)
+		if s.cfg.Latency > 0 {
+			select {
+			case <-time.After(s.cfg.Latency):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		if s.cfg.RateLimit > 0 {
+			w.Header().Set("X-Rate-Limit-Limit", strconv.Itoa(s.cfg.RateLimit))
+			w.Header().Set("X-Rate-Limit-Remaining", strconv.Itoa(max(s.cfg.RateLimit-count, 0)))
+			w.Header().Set("X-Rate-Limit-Reset", strconv.Itoa(secondsToMidnight(s.now())))
+			if count > s.cfg.RateLimit {
+				writeError(w, http.StatusTooManyRequests, "Request limit reached")
+				return
+			}
+		}
+		if fail {
+			writeError(w, s.cfg.ErrorStatus, "Injected error")
+			return
+		}
+
+		query := r.URL.Query()
+		country := strings.ToUpper(query.Get("country_id"))
+		names, batch := query["name[]"]
+		if !batch {
+			names = query["name"]
+		}
+		if len(names) == 0 {
+			writeError(w, http.StatusUnprocessableEntity, "Missing 'name' parameter")
+			return
+		}
+
+		bodies := make([]map[string]interface{}, len(names))
+		for i, name := range names {
+			answer := s.cfg.Answers[normalize(name)]
+			if answer.Status != 0 {
+				writeError(w, answer.Status, "Injected error for "+name)
+				return
+			}
+			bodies[i] = respond(provider, name, country, answer)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if batch {
+			json.NewEncoder(w).Encode(bodies)
+			return
+		}
+		json.NewEncoder(w).Encode(bodies[0])
+	}
+}
+
+// admit counts the request and decides whether to inject an error. It
+// returns the number of requests provider has received today.
+func (s *Server) admit(provider string) (int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if today := s.now().UTC().Truncate(24 * time.Hour); !today.Equal(s.day) {
+		s.day = today
+		clear(s.used)
+	}
+	s.requests[provider]++
+	s.used[provider]++
+	fail := s.cfg.ErrorRate > 0 && s.rand.Float64() < s.cfg.ErrorRate
+	return s.used[provider], fail
+}
+
+// respond builds the answer of provider for name: the override where set and
+// otherwise values derived from a hash of the name and country, so the same
+// question always gets the same answer.
+func respond(provider, name, country string, answer Answer) map[string]interface{} {
+	body := map[string]interface{}{"name": name}
+	if country != "" {
+		body["country_id"] = country
+	}
+	if answer.Unknown {
+		body["count"] = 0
+		switch provider {
+		case Agify:
+			body["age"] = nil
+		case Genderize:
+			body["gender"], body["probability"] = nil, 0.0
+		case Nationalize:
+			body["country"] = []Country{}
+		}
+		return body
+	}
+
+	h := hash(normalize(name) + "|" + country)
+	body["count"] = int(1 + h%50000)
+	if answer.Count != nil {
+		body["count"] = *answer.Count
+	}
+	switch provider {
+	case Agify:
+		age := int(18 + (h>>16)%63)
+		if answer.Age != nil {
+			age = *answer.Age
+		}
+		body["age"] = age
+	case Genderize:
+		gender := []string{"male", "female"}[(h>>24)%2]
+		if answer.Gender != "" {
+			gender = answer.Gender
+		}
+		probability := 0.5 + float64((h>>32)%50)/100
+		if answer.Probability != nil {
+			probability = *answer.Probability
+		}
+		body["gender"], body["probability"] = gender, probability
+	case Nationalize:
+		countries := answer.Countries
+		if countries == nil {
+			countries = generateCountries(h)
+		}
+		body["country"] = countries
+	}
+	return body
+}
+
+var countryPool = []string{"US", "GB", "DE", "FR", "IT", "ES", "RU", "UA", "KZ", "PL", "TR", "BR", "IN", "JP", "NG"}
+
+func generateCountries(h uint64) []Country {
+	first := int(h>>40) % len(countryPool)
+	p := 0.3 + float64((h>>48)%40)/100
+	countries := make([]Country, 0, 3)
+	for i := range 3 {
+		countries = append(countries, Country{
+			CountryID:   countryPool[(first+i*5)%len(countryPool)],
+			Probability: float64(int(p*100)) / 100,
+		})
+		p /= 2
+	}
+	return countries
+}
+
+func hash(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return h.Sum64()
+}
+
+func normalize(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+func secondsToMidnight(now time.Time) int {
+	now = now.UTC()
+	return int(now.Truncate(24 * time.Hour).Add(24 * time.Hour).Sub(now).Seconds())
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
@@ -0,0 +1,151 @@
+package fakeproviders
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func get(t *testing.T, srv *httptest.Server, path string, out interface{}) *http.Response {
+	t.Helper()
+	resp, err := http.Get(srv.URL + path)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	if out != nil && resp.StatusCode == http.StatusOK {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(out))
+	}
+	return resp
+}
+
+func TestServer_AnswersAreDeterministic(t *testing.T) {
+	srv := httptest.NewServer(New(Config{}))
+	defer srv.Close()
+
+	var first, second struct {
+		Name  string `json:"name"`
+		Age   int    `json:"age"`
+		Count int    `json:"count"`
+	}
+	get(t, srv, "/agify?name=Dmitriy", &first)
+	get(t, srv, "/agify/?name=dmitriy", &second)
+	assert.Equal(t, "Dmitriy", first.Name)
+	assert.Equal(t, first.Age, second.Age)
+	assert.GreaterOrEqual(t, first.Age, 18)
+	assert.Positive(t, first.Count)
+
+	var gender struct {
+		Gender      string  `json:"gender"`
+		Probability float64 `json:"probability"`
+	}
+	get(t, srv, "/genderize?name=Dmitriy", &gender)
+	assert.Contains(t, []string{"male", "female"}, gender.Gender)
+	assert.GreaterOrEqual(t, gender.Probability, 0.5)
+}
+
+func TestServer_Overrides(t *testing.T) {
+	age := 42
+	srv := httptest.NewServer(New(Config{Answers: map[string]Answer{
+		"Dmitriy": {Age: &age, Gender: "male", Countries: []Country{{CountryID: "RU", Probability: 0.8}}},
+		"Zed":     {Unknown: true},
+		"Broken":  {Status: http.StatusBadGateway},
+	}}))
+	defer srv.Close()
+
+	var agify struct{ Age *int }
+	get(t, srv, "/agify?name=dmitriy", &agify)
+	assert.Equal(t, 42, *agify.Age)
+
+	var nationalize struct {
+		Country []Country `json:"country"`
+	}
+	get(t, srv, "/nationalize?name=Dmitriy", &nationalize)
+	assert.Equal(t, []Country{{CountryID: "RU", Probability: 0.8}}, nationalize.Country)
+
+	var unknown struct {
+		Gender *string `json:"gender"`
+		Count  int     `json:"count"`
+	}
+	get(t, srv, "/genderize?name=Zed", &unknown)
+	assert.Nil(t, unknown.Gender)
+	assert.Zero(t, unknown.Count)
+
+	resp := get(t, srv, "/genderize?name=Broken", nil)
+	assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+}
+
+func TestServer_Batch(t *testing.T) {
+	srv := httptest.NewServer(New(Config{}))
+	defer srv.Close()
+
+	var answers []struct {
+		Name      string `json:"name"`
+		CountryID string `json:"country_id"`
+	}
+	get(t, srv, "/agify?name[]=Anna&name[]=Kim&country_id=it", &answers)
+	require.Len(t, answers, 2)
+	assert.Equal(t, "Kim", answers[1].Name)
+	assert.Equal(t, "IT", answers[1].CountryID)
+}
+
+func TestServer_ErrorInjectionAndRateLimit(t *testing.T) {
+	srv := httptest.NewServer(New(Config{ErrorRate: 1, ErrorStatus: http.StatusServiceUnavailable}))
+	defer srv.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, get(t, srv, "/agify?name=Anna", nil).StatusCode)
+
+	fake := New(Config{RateLimit: 1})
+	limited := httptest.NewServer(fake)
+	defer limited.Close()
+	resp := get(t, limited, "/agify?name=Anna", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "0", resp.Header.Get("X-Rate-Limit-Remaining"))
+	assert.Equal(t, http.StatusTooManyRequests, get(t, limited, "/agify?name=Anna", nil).StatusCode)
+	assert.Equal(t, 2, fake.Requests(Agify))
+	assert.Zero(t, fake.Requests(Genderize))
+}
+
+func TestServer_RateLimitResetsAtMidnightUTC(t *testing.T) {
+	fake := New(Config{RateLimit: 1})
+	now := time.Date(2025, 6, 19, 23, 59, 0, 0, time.UTC)
+	fake.now = func() time.Time { return now }
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	assert.Equal(t, http.StatusOK, get(t, srv, "/agify?name=Anna", nil).StatusCode)
+	resp := get(t, srv, "/agify?name=Anna", nil)
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "60", resp.Header.Get("X-Rate-Limit-Reset"))
+
+	now = now.Add(2 * time.Minute)
+	resp = get(t, srv, "/agify?name=Anna", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "0", resp.Header.Get("X-Rate-Limit-Remaining"))
+	assert.Equal(t, 3, fake.Requests(Agify))
+}
+
+func TestServer_Latency(t *testing.T) {
+	srv := httptest.NewServer(New(Config{Latency: 50 * time.Millisecond}))
+	defer srv.Close()
+
+	start := time.Now()
+	get(t, srv, "/agify?name=Anna", nil)
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+}
+
+func TestLoadAnswers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "answers.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"Anna": {"gender": "female", "probability": 0.97}}`), 0o600))
+
+	answers, err := LoadAnswers(path)
+	require.NoError(t, err)
+	assert.Equal(t, "female", answers["Anna"].Gender)
+
+	_, err = LoadAnswers(filepath.Join(t.TempDir(), "missing.json"))
+	assert.ErrorContains(t, err, "failed to read answers")
+}
@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/adal4ik/people-enrichment-service/utils"
+	"go.uber.org/zap"
+)
+
+// writeError logs the failure and sends it to the client as an APIError.
+func writeError(logger *zap.Logger, w http.ResponseWriter, r *http.Request, code int, message string, err error) {
+	if err != nil {
+		logger.Error(message,
+			zap.Error(err),
+			zap.Int("code", code),
+			zap.String("url", r.URL.Path),
+		)
+	} else {
+		logger.Error(message,
+			zap.Int("code", code),
+			zap.String("url", r.URL.Path),
+		)
+	}
+
+	jsonErr := utils.APIError{
+		Code:     code,
+		Message:  message,
+		Resource: r.URL.Path,
+	}
+	jsonErr.Send(w)
+}
@@ -6,11 +6,13 @@ import (
 )
 
 type Handler struct {
-	PersonHandler *PersonHandler
+	PersonHandler   *PersonHandler
+	ProviderHandler *ProviderHandler
 }
 
 func New(services *service.Service, logger *zap.Logger) *Handler {
 	return &Handler{
-		PersonHandler: NewPersonHandler(services.PersonService, logger),
+		PersonHandler:   NewPersonHandler(services.PersonService, logger),
+		ProviderHandler: NewProviderHandler(services.ProviderService, logger),
 	}
 }
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
@@ -29,25 +30,7 @@ func NewPersonHandler(service service.PersonServiceInterface, logger *zap.Logger
 }
 
 func (p *PersonHandler) handleError(w http.ResponseWriter, r *http.Request, code int, message string, err error) {
-	if err != nil {
-		p.logger.Error(message,
-			zap.Error(err),
-			zap.Int("code", code),
-			zap.String("url", r.URL.Path),
-		)
-	} else {
-		p.logger.Error(message,
-			zap.Int("code", code),
-			zap.String("url", r.URL.Path),
-		)
-	}
-
-	jsonErr := utils.APIError{
-		Code:     code,
-		Message:  message,
-		Resource: r.URL.Path,
-	}
-	jsonErr.Send(w)
+	writeError(p.logger, w, r, code, message, err)
 }
 
 func (p *PersonHandler) CreatePerson(w http.ResponseWriter, req *http.Request) {
@@ -66,34 +49,147 @@ func (p *PersonHandler) CreatePerson(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	countryHint, ok := parseCountryHint(r.CountryHint)
+	if !ok {
+		p.handleError(w, req, 400, "country_hint must be a two-letter ISO 3166-1 code", nil)
+		return
+	}
+
 	var patronymic *string
 	if r.Patronymic != "" {
 		patronymic = &r.Patronymic
 	}
 
 	person := models.Person{
-		ID:         uuid.New(),
-		Name:       r.Name,
-		Surname:    r.Surname,
-		Patronymic: patronymic,
+		ID:          uuid.New(),
+		Name:        r.Name,
+		Surname:     r.Surname,
+		Patronymic:  patronymic,
+		CountryHint: countryHint,
 	}
 
 	p.logger.Debug("received person payload", zap.Any("person", person))
 
-	err = p.service.CreatePerson(req.Context(), person)
+	created, err := p.service.CreatePerson(req.Context(), person)
+	if errors.Is(err, utils.ErrCircuitOpen) || errors.Is(err, utils.ErrQuotaExhausted) {
+		p.handleError(w, req, 503, "enrichment provider unavailable", err)
+		return
+	}
+	if errors.Is(err, utils.ErrEnrichmentNotQueued) {
+		p.handleError(w, req, 503, "enrichment queue unavailable, person not saved", err)
+		return
+	}
 	if err != nil {
 		p.handleError(w, req, 500, "failed to save person", err)
 		return
 	}
 
+	if created.EnrichmentStatus == models.EnrichmentPending {
+		p.logger.Info("person accepted for enrichment", zap.String("name", person.Name), zap.Any("id", created.ID))
+		resp := utils.CreatedResponse{
+			Code:             202,
+			Message:          "Accepted, enrichment in progress",
+			ID:               created.ID.String(),
+			EnrichmentStatus: created.EnrichmentStatus,
+		}
+		resp.Send(w)
+		return
+	}
+
 	p.logger.Info("person inserted successfully", zap.String("name", person.Name))
-	resp := utils.APIResponse{
-		Code:    201,
-		Message: "Successfully created",
+	resp := utils.CreatedResponse{
+		Code:                    201,
+		Message:                 "Successfully created",
+		ID:                      created.ID.String(),
+		EnrichmentStatus:        created.EnrichmentStatus,
+		FailedAttributes:        created.FailedAttributes(),
+		LowConfidenceAttributes: created.LowConfidenceAttributes(),
 	}
 	resp.Send(w)
 }
 
+// parseCountryHint upper-cases an optional alpha-2 country code. It returns
+// false when hint is neither empty nor two letters.
+func parseCountryHint(hint string) (*string, bool) {
+	hint = strings.ToUpper(strings.TrimSpace(hint))
+	if hint == "" {
+		return nil, true
+	}
+	if len(hint) != 2 || hint[0] < 'A' || hint[0] > 'Z' || hint[1] < 'A' || hint[1] > 'Z' {
+		return nil, false
+	}
+	return &hint, true
+}
+
+// maxBatchCreate bounds the number of persons accepted by a single bulk create request.
+const maxBatchCreate = 100
+
+func (p *PersonHandler) CreatePersons(w http.ResponseWriter, req *http.Request) {
+	var r []models.CreatePerson
+	if err := json.NewDecoder(req.Body).Decode(&r); err != nil {
+		p.handleError(w, req, 400, "failed to decode request body", err)
+		return
+	}
+	if len(r) == 0 {
+		p.handleError(w, req, 400, "at least one person is required", nil)
+		return
+	}
+	if len(r) > maxBatchCreate {
+		p.handleError(w, req, 400, fmt.Sprintf("at most %d persons can be created at once", maxBatchCreate), nil)
+		return
+	}
+
+	persons := make([]models.Person, len(r))
+	for i, item := range r {
+		if item.Name == "" || item.Surname == "" {
+			p.handleError(w, req, 400, fmt.Sprintf("name and surname are required (item %d)", i), nil)
+			return
+		}
+		countryHint, ok := parseCountryHint(item.CountryHint)
+		if !ok {
+			p.handleError(w, req, 400, fmt.Sprintf("country_hint must be a two-letter ISO 3166-1 code (item %d)", i), nil)
+			return
+		}
+		var patronymic *string
+		if item.Patronymic != "" {
+			patronymic = &item.Patronymic
+		}
+		persons[i] = models.Person{
+			ID:          uuid.New(),
+			Name:        item.Name,
+			Surname:     item.Surname,
+			Patronymic:  patronymic,
+			CountryHint: countryHint,
+		}
+	}
+
+	p.logger.Debug("received batch payload", zap.Int("count", len(persons)))
+	errs := p.service.CreatePersons(req.Context(), persons)
+
+	results := make([]models.BatchCreateResult, len(persons))
+	created := 0
+	for i, person := range persons {
+		results[i] = models.BatchCreateResult{Index: i, Name: person.Name}
+		if errs[i] != nil {
+			results[i].Error = errs[i].Error()
+			continue
+		}
+		id := person.ID
+		results[i].ID = &id
+		results[i].EnrichmentStatus = person.EnrichmentStatus
+		results[i].FailedAttributes = person.FailedAttributes()
+		results[i].LowConfidenceAttributes = person.LowConfidenceAttributes()
+		created++
+	}
+	p.logger.Info("batch create finished", zap.Int("created", created), zap.Int("failed", len(persons)-created))
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(results); err != nil {
+		p.handleError(w, req, 500, "failed to encode response", err)
+		return
+	}
+}
+
 func (p *PersonHandler) GetPersons(w http.ResponseWriter, req *http.Request) {
 	query := req.URL.Query()
 	limitStr := query.Get("limit")
@@ -108,8 +204,9 @@ func (p *PersonHandler) GetPersons(w http.ResponseWriter, req *http.Request) {
 
 	limit := 10
 	offset := 0
+	// Without age bounds persons of unknown age are listed too.
 	ageMin := 0
-	ageMax := 200
+	ageMax := -1
 
 	if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
 		limit = l
@@ -124,7 +221,7 @@ func (p *PersonHandler) GetPersons(w http.ResponseWriter, req *http.Request) {
 	if aMax, err := strconv.Atoi(ageMaxStr); err == nil && aMax >= 0 {
 		ageMax = aMax
 	}
-	if ageMin > ageMax {
+	if ageMax >= 0 && ageMin > ageMax {
 		p.handleError(w, req, 400, "age_min cannot be greater than age_max", nil)
 		return
 	}
@@ -184,9 +281,9 @@ func (p *PersonHandler) GetPerson(w http.ResponseWriter, req *http.Request) {
 		zap.String("id", id),
 		zap.String("name", person.Name),
 		zap.String("surname", person.Surname),
-		zap.Int("age", *person.Age),
-		zap.String("gender", *person.Gender),
-		zap.String("nationality", *person.Nationality),
+		zap.Any("age", person.Age),
+		zap.Any("gender", person.Gender),
+		zap.Any("nationality", person.Nationality),
 	)
 }
 
@@ -266,3 +363,88 @@ func (p *PersonHandler) UpdatePerson(w http.ResponseWriter, req *http.Request) {
 	}
 	resp.Send(w)
 }
+
+// ReEnrichPerson re-runs enrichment for a stored person, optionally limited to
+// ?fields=age,gender, and responds with the before/after values.
+func (p *PersonHandler) ReEnrichPerson(w http.ResponseWriter, req *http.Request) {
+	id := chi.URLParam(req, "id")
+	uuidValue, err := uuid.Parse(id)
+	if err != nil {
+		p.handleError(w, req, 400, "invalid UUID format for id", err)
+		return
+	}
+	attrs, err := service.ParseAttributes(req.URL.Query().Get("fields"))
+	if err != nil {
+		p.handleError(w, req, 400, "invalid fields", err)
+		return
+	}
+
+	p.logger.Debug("ReEnrichPerson request", zap.String("id", id), zap.Any("fields", attrs))
+	diff, err := p.service.ReEnrichPerson(req.Context(), uuidValue, attrs)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows), errors.Is(err, utils.ErrPersonNotFound):
+			p.handleError(w, req, 404, "person not found", nil)
+		case errors.Is(err, utils.ErrNoProvider):
+			p.handleError(w, req, 400, "no provider configured for requested field", err)
+		case errors.Is(err, utils.ErrCircuitOpen), errors.Is(err, utils.ErrQuotaExhausted):
+			p.handleError(w, req, 503, "enrichment provider unavailable", err)
+		default:
+			p.handleError(w, req, 500, "failed to re-enrich person", err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(diff); err != nil {
+		p.handleError(w, req, 500, "failed to encode response", err)
+		return
+	}
+	p.logger.Info("person re-enriched", zap.String("id", id), zap.String("status", diff.EnrichmentStatus))
+}
+
+// PreviewEnrichment predicts the attributes of ?name= (with optional surname,
+// patronymic and country_hint) without saving a person.
+func (p *PersonHandler) PreviewEnrichment(w http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+	name := strings.TrimSpace(query.Get("name"))
+	if name == "" {
+		p.handleError(w, req, 400, "name is required", nil)
+		return
+	}
+	countryHint, ok := parseCountryHint(query.Get("country_hint"))
+	if !ok {
+		p.handleError(w, req, 400, "country_hint must be a two-letter ISO 3166-1 code", nil)
+		return
+	}
+	var patronymic *string
+	if value := strings.TrimSpace(query.Get("patronymic")); value != "" {
+		patronymic = &value
+	}
+
+	person := models.Person{
+		Name:        name,
+		Surname:     strings.TrimSpace(query.Get("surname")),
+		Patronymic:  patronymic,
+		CountryHint: countryHint,
+	}
+	p.logger.Debug("PreviewEnrichment request", zap.Any("person", person))
+
+	preview, err := p.service.PreviewEnrichment(req.Context(), person)
+	if err != nil {
+		switch {
+		case errors.Is(err, utils.ErrCircuitOpen), errors.Is(err, utils.ErrQuotaExhausted):
+			p.handleError(w, req, 503, "enrichment provider unavailable", err)
+		default:
+			p.handleError(w, req, 500, "failed to preview enrichment", err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(preview); err != nil {
+		p.handleError(w, req, 500, "failed to encode response", err)
+		return
+	}
+	p.logger.Info("enrichment previewed", zap.String("name", name), zap.String("status", preview.EnrichmentStatus))
+}
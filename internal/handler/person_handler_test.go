@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/adal4ik/people-enrichment-service/internal/models"
+	"github.com/adal4ik/people-enrichment-service/internal/service"
+	"github.com/adal4ik/people-enrichment-service/utils"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// stubPersonService answers PreviewEnrichment with a fixed error; the other
+// methods are not used by these tests.
+type stubPersonService struct {
+	service.PersonServiceInterface
+	previewErr error
+}
+
+func (s *stubPersonService) PreviewEnrichment(ctx context.Context, person models.Person) (models.EnrichmentPreview, error) {
+	return models.EnrichmentPreview{}, s.previewErr
+}
+
+func TestPreviewEnrichment_ProviderUnavailable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{fmt.Errorf("genderize: %w", utils.ErrCircuitOpen), http.StatusServiceUnavailable},
+		{fmt.Errorf("agify: %w", utils.ErrQuotaExhausted), http.StatusServiceUnavailable},
+		{fmt.Errorf("unexpected"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		h := NewPersonHandler(&stubPersonService{previewErr: tt.err}, zap.NewNop())
+		rec := httptest.NewRecorder()
+		h.PreviewEnrichment(rec, httptest.NewRequest(http.MethodGet, "/enrich?name=Dmitry", nil))
+		assert.Equal(t, tt.want, rec.Code, tt.err.Error())
+	}
+}
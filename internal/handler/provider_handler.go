@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/adal4ik/people-enrichment-service/internal/service"
+	"github.com/adal4ik/people-enrichment-service/utils"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+type ProviderHandler struct {
+	service service.ProviderServiceInterface
+	logger  *zap.Logger
+}
+
+func NewProviderHandler(service service.ProviderServiceInterface, logger *zap.Logger) *ProviderHandler {
+	return &ProviderHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (p *ProviderHandler) handleError(w http.ResponseWriter, r *http.Request, code int, message string, err error) {
+	writeError(p.logger, w, r, code, message, err)
+}
+
+func (p *ProviderHandler) GetStatus(w http.ResponseWriter, req *http.Request) {
+	statuses := p.service.Status()
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(statuses); err != nil {
+		p.handleError(w, req, 500, "failed to encode response", err)
+		return
+	}
+}
+
+func (p *ProviderHandler) GetQuota(w http.ResponseWriter, req *http.Request) {
+	quota, ok := p.service.Quota()
+	if !ok {
+		p.handleError(w, req, 404, "provider rate limiting is disabled", nil)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(quota); err != nil {
+		p.handleError(w, req, 500, "failed to encode response", err)
+		return
+	}
+}
+
+func (p *ProviderHandler) GetCacheStats(w http.ResponseWriter, req *http.Request) {
+	stats, ok := p.service.CacheStats()
+	if !ok {
+		p.handleError(w, req, 404, "enrichment cache is disabled", nil)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(stats); err != nil {
+		p.handleError(w, req, 500, "failed to encode response", err)
+		return
+	}
+}
+
+func (p *ProviderHandler) GetCoalescing(w http.ResponseWriter, req *http.Request) {
+	statuses, ok := p.service.Coalescing()
+	if !ok {
+		p.handleError(w, req, 404, "request coalescing is disabled", nil)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(statuses); err != nil {
+		p.handleError(w, req, 500, "failed to encode response", err)
+		return
+	}
+}
+
+func (p *ProviderHandler) GetUsage(w http.ResponseWriter, req *http.Request) {
+	days := 7
+	if v := req.URL.Query().Get("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 366 {
+			p.handleError(w, req, 400, "days must be an integer between 1 and 366", err)
+			return
+		}
+		days = n
+	}
+
+	usage, ok, err := p.service.Usage(req.Context(), days)
+	if !ok {
+		p.handleError(w, req, 404, "provider usage tracking is disabled", nil)
+		return
+	}
+	if err != nil {
+		p.handleError(w, req, 500, "failed to get provider usage", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(usage); err != nil {
+		p.handleError(w, req, 500, "failed to encode response", err)
+		return
+	}
+}
+
+func (p *ProviderHandler) InvalidateCache(w http.ResponseWriter, req *http.Request) {
+	name := strings.TrimSpace(chi.URLParam(req, "name"))
+	if name == "" {
+		p.handleError(w, req, 400, "name parameter is required", nil)
+		return
+	}
+	provider := req.URL.Query().Get("provider")
+	p.logger.Debug("InvalidateCache request", zap.String("name", name), zap.String("provider", provider))
+
+	removed, err := p.service.InvalidateCache(req.Context(), name, provider)
+	if err != nil {
+		p.handleError(w, req, 500, "failed to invalidate cache", err)
+		return
+	}
+	resp := utils.APIResponse{
+		Code:    200,
+		Message: fmt.Sprintf("Successfully invalidated %d cached entries", removed),
+	}
+	resp.Send(w)
+}
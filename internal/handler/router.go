@@ -20,10 +20,20 @@ func Router(handlers Handler) http.Handler {
 	}))
 
 	r.Post("/person", handlers.PersonHandler.CreatePerson)
+	r.Post("/persons/batch", handlers.PersonHandler.CreatePersons)
 	r.Get("/persons", handlers.PersonHandler.GetPersons)
 	r.Get("/person/{id}", handlers.PersonHandler.GetPerson)
 	r.Delete("/person/{id}", handlers.PersonHandler.DeletePerson)
 	r.Put("/person/{id}", handlers.PersonHandler.UpdatePerson)
+	r.Post("/person/{id}/enrich", handlers.PersonHandler.ReEnrichPerson)
+	r.Get("/enrich", handlers.PersonHandler.PreviewEnrichment)
+
+	r.Get("/providers/status", handlers.ProviderHandler.GetStatus)
+	r.Get("/providers/quota", handlers.ProviderHandler.GetQuota)
+	r.Get("/providers/usage", handlers.ProviderHandler.GetUsage)
+	r.Get("/providers/coalescing", handlers.ProviderHandler.GetCoalescing)
+	r.Get("/cache/stats", handlers.ProviderHandler.GetCacheStats)
+	r.Delete("/cache/{name}", handlers.ProviderHandler.InvalidateCache)
 
 	return r
 }
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Enrichment records where an enriched attribute came from and how much it can be trusted.
+// Error is set instead of a value when the attribute could not be enriched, and
+// LowConfidence when the value was too weak to be stored on the person.
+// Strategy and Sources record how a value was agreed on when several providers
+// were consulted; Provider is then the source whose answer was kept.
+type Enrichment struct {
+	Provider      string          `json:"provider"`
+	Value         *string         `json:"value"`
+	Probability   *float64        `json:"probability,omitempty"`
+	SampleCount   *int            `json:"sample_count,omitempty"`
+	Evidence      json.RawMessage `json:"evidence,omitempty"`
+	Strategy      string          `json:"strategy,omitempty"`
+	Sources       []string        `json:"sources,omitempty"`
+	Error         *string         `json:"error,omitempty"`
+	LowConfidence bool            `json:"low_confidence,omitempty"`
+	FetchedAt     time.Time       `json:"fetched_at"`
+}
+
+// FieldChange is the value of a person field before and after re-enrichment.
+type FieldChange struct {
+	Before  interface{} `json:"before"`
+	After   interface{} `json:"after"`
+	Changed bool        `json:"changed"`
+}
+
+// EnrichmentDiff reports the outcome of re-enriching a stored person.
+type EnrichmentDiff struct {
+	ID               uuid.UUID              `json:"id"`
+	EnrichmentStatus string                 `json:"enrichment_status"`
+	Changes          map[string]FieldChange `json:"changes"`
+	FailedAttributes map[string]string      `json:"failed_attributes,omitempty"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import "github.com/google/uuid"
+
+// EnrichmentJob is a claimed entry of the durable enrichment queue. Lease
+// identifies the claim; a job reclaimed after its visibility timeout gets a
+// new one, and reports made under the old lease are rejected.
+type EnrichmentJob struct {
+	ID       int64
+	PersonID uuid.UUID
+	Attempts int
+	Lease    uuid.UUID
+}
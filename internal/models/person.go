@@ -1,27 +1,105 @@
 package models
 
 import (
+	"sort"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// Enrichment statuses of a person record.
+const (
+	EnrichmentPending   = "pending"
+	EnrichmentCompleted = "completed"
+	EnrichmentFailed    = "failed"
+	// EnrichmentPartial means some attributes could not be enriched, see FailedAttributes.
+	EnrichmentPartial = "partial"
+)
+
 type Person struct {
-	ID          uuid.UUID `json:"id"`
-	Name        string    `json:"name"`
-	Surname     string    `json:"surname"`
-	Patronymic  *string   `json:"patronymic"`
-	Age         *int      `json:"age"`
-	Gender      *string   `json:"gender"`
-	Nationality *string   `json:"nationality"`
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+	// NameNormalized is the trimmed, transliterated and lower-cased name used for searching.
+	NameNormalized *string `json:"name_normalized,omitempty"`
+	Surname        string  `json:"surname"`
+	Patronymic     *string `json:"patronymic"`
+	Age            *int    `json:"age"`
+	Gender         *string `json:"gender"`
+	Nationality    *string `json:"nationality"`
+	// CountryHint is the client-supplied country used to localize age and gender.
+	CountryHint *string   `json:"country_hint,omitempty"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+
+	EnrichmentStatus string  `json:"enrichment_status"`
+	EnrichmentError  *string `json:"enrichment_error,omitempty"`
+
+	Enrichment map[string]Enrichment `json:"enrichment,omitempty"`
 }
 
 type CreatePerson struct {
 	Name       string `json:"name"`
 	Surname    string `json:"surname"`
 	Patronymic string `json:"patronymic"`
+	// CountryHint is an optional ISO 3166-1 alpha-2 code, e.g. "IT".
+	CountryHint string `json:"country_hint"`
+}
+
+// FailedAttributes maps every attribute that could not be enriched to the reason.
+func (p Person) FailedAttributes() map[string]string {
+	var failed map[string]string
+	for attr, e := range p.Enrichment {
+		if e.Error == nil {
+			continue
+		}
+		if failed == nil {
+			failed = make(map[string]string)
+		}
+		failed[attr] = *e.Error
+	}
+	return failed
+}
+
+// LowConfidenceAttributes lists the attributes left unknown because the
+// provider answer was below the confidence threshold.
+func (p Person) LowConfidenceAttributes() []string {
+	var attrs []string
+	for attr, e := range p.Enrichment {
+		if e.LowConfidence {
+			attrs = append(attrs, attr)
+		}
+	}
+	sort.Strings(attrs)
+	return attrs
+}
+
+// BatchCreateResult reports the outcome for one entry of a bulk create request.
+type BatchCreateResult struct {
+	Index int        `json:"index"`
+	ID    *uuid.UUID `json:"id,omitempty"`
+	Name  string     `json:"name"`
+	Error string     `json:"error,omitempty"`
+
+	EnrichmentStatus        string            `json:"enrichment_status,omitempty"`
+	FailedAttributes        map[string]string `json:"failed_attributes,omitempty"`
+	LowConfidenceAttributes []string          `json:"low_confidence_attributes,omitempty"`
+}
+
+// EnrichmentPreview holds the predicted attributes of a name that is not stored.
+type EnrichmentPreview struct {
+	Name           string  `json:"name"`
+	NameNormalized string  `json:"name_normalized"`
+	Surname        string  `json:"surname,omitempty"`
+	Patronymic     *string `json:"patronymic,omitempty"`
+	CountryHint    *string `json:"country_hint,omitempty"`
+	Age            *int    `json:"age"`
+	Gender         *string `json:"gender"`
+	Nationality    *string `json:"nationality"`
+
+	EnrichmentStatus        string                `json:"enrichment_status"`
+	Enrichment              map[string]Enrichment `json:"enrichment,omitempty"`
+	FailedAttributes        map[string]string     `json:"failed_attributes,omitempty"`
+	LowConfidenceAttributes []string              `json:"low_confidence_attributes,omitempty"`
 }
 
 type UpdatePerson struct {
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// ProviderUsage is the number of names a provider answered with one API key
+// on one UTC day. KeyID is a fingerprint of the key, empty for keyless calls.
+type ProviderUsage struct {
+	Provider string    `json:"provider"`
+	KeyID    string    `json:"key_id"`
+	Day      time.Time `json:"day"`
+	Requests int64     `json:"requests"`
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/adal4ik/people-enrichment-service/internal/models"
+	"go.uber.org/zap"
+)
+
+type CacheRepositoryInterface interface {
+	GetCachedEnrichment(ctx context.Context, provider, name, countryID string, since time.Time) (models.Enrichment, error)
+	SaveCachedEnrichment(ctx context.Context, name, countryID, attribute string, enrichment models.Enrichment) error
+	InvalidateCachedEnrichments(ctx context.Context, name, provider string) (int64, error)
+}
+
+type CacheRepository struct {
+	logger *zap.Logger
+	db     *sql.DB
+}
+
+func NewCacheRepository(db *sql.DB, logger *zap.Logger) *CacheRepository {
+	return &CacheRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// GetCachedEnrichment returns the cached answer of provider for name, localized
+// to countryID ("" when not localized), fetched after since.
+// sql.ErrNoRows is returned when there is no fresh entry.
+func (c *CacheRepository) GetCachedEnrichment(ctx context.Context, provider, name, countryID string, since time.Time) (models.Enrichment, error) {
+	query := `
+		SELECT provider, value, probability, sample_count, evidence, fetched_at
+		FROM name_enrichment_cache
+		WHERE provider = $1 AND name = $2 AND country_id = $3 AND fetched_at > $4
+	`
+	c.logger.Debug("executing cache lookup", zap.String("query", query), zap.String("provider", provider), zap.String("name", name))
+
+	var (
+		e        models.Enrichment
+		evidence []byte
+	)
+	err := c.db.QueryRowContext(ctx, query, provider, name, countryID, since).Scan(
+		&e.Provider,
+		&e.Value,
+		&e.Probability,
+		&e.SampleCount,
+		&evidence,
+		&e.FetchedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Enrichment{}, sql.ErrNoRows
+		}
+		return models.Enrichment{}, fmt.Errorf("failed to get cached enrichment: %w", err)
+	}
+	if len(evidence) > 0 {
+		e.Evidence = evidence
+	}
+	return e, nil
+}
+
+func (c *CacheRepository) SaveCachedEnrichment(ctx context.Context, name, countryID, attribute string, enrichment models.Enrichment) error {
+	query := `
+		INSERT INTO name_enrichment_cache (provider, name, country_id, attribute, value, probability, sample_count, evidence, fetched_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (provider, name, country_id) DO UPDATE SET
+			attribute = EXCLUDED.attribute,
+			value = EXCLUDED.value,
+			probability = EXCLUDED.probability,
+			sample_count = EXCLUDED.sample_count,
+			evidence = EXCLUDED.evidence,
+			fetched_at = EXCLUDED.fetched_at
+	`
+	c.logger.Debug("executing cache upsert", zap.String("query", query), zap.String("provider", enrichment.Provider), zap.String("name", name))
+
+	var evidence interface{}
+	if len(enrichment.Evidence) > 0 {
+		evidence = []byte(enrichment.Evidence)
+	}
+	_, err := c.db.ExecContext(ctx, query,
+		enrichment.Provider,
+		name,
+		countryID,
+		attribute,
+		enrichment.Value,
+		enrichment.Probability,
+		enrichment.SampleCount,
+		evidence,
+		enrichment.FetchedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save cached enrichment: %w", err)
+	}
+	return nil
+}
+
+// InvalidateCachedEnrichments removes cached answers for name in every country.
+// An empty provider removes the entries of every provider.
+func (c *CacheRepository) InvalidateCachedEnrichments(ctx context.Context, name, provider string) (int64, error) {
+	query := `DELETE FROM name_enrichment_cache WHERE name = $1`
+	args := []interface{}{name}
+	if provider != "" {
+		query += ` AND provider = $2`
+		args = append(args, provider)
+	}
+	c.logger.Debug("executing cache delete", zap.String("query", query), zap.Any("args", args))
+
+	res, err := c.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to invalidate cached enrichments: %w", err)
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rowsAffected, nil
+}
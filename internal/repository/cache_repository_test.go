@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+)
+
+func newTestCacheRepo(t *testing.T) (*CacheRepository, sqlmock.Sqlmock, func()) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	return NewCacheRepository(db, zaptest.NewLogger(t)), mock, func() { db.Close() }
+}
+
+func TestGetCachedEnrichment_Success(t *testing.T) {
+	repo, mock, close := newTestCacheRepo(t)
+	defer close()
+
+	since := time.Now().Add(-time.Hour)
+	fetchedAt := time.Now()
+	rows := sqlmock.NewRows([]string{"provider", "value", "probability", "sample_count", "evidence", "fetched_at"}).
+		AddRow("genderize", "male", 0.99, 1200, nil, fetchedAt)
+	mock.ExpectQuery("SELECT provider, value, probability, sample_count, evidence, fetched_at FROM name_enrichment_cache").
+		WithArgs("genderize", "ivan", "", since).
+		WillReturnRows(rows)
+
+	e, err := repo.GetCachedEnrichment(context.Background(), "genderize", "ivan", "", since)
+	assert.NoError(t, err)
+	assert.Equal(t, "male", *e.Value)
+	assert.Equal(t, 1200, *e.SampleCount)
+	assert.Nil(t, e.Evidence)
+}
+
+func TestGetCachedEnrichment_Miss(t *testing.T) {
+	repo, mock, close := newTestCacheRepo(t)
+	defer close()
+
+	mock.ExpectQuery("SELECT provider, value, probability, sample_count, evidence, fetched_at FROM name_enrichment_cache").
+		WillReturnError(sql.ErrNoRows)
+
+	_, err := repo.GetCachedEnrichment(context.Background(), "genderize", "ivan", "", time.Now())
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestInvalidateCachedEnrichments_AllProviders(t *testing.T) {
+	repo, mock, close := newTestCacheRepo(t)
+	defer close()
+
+	mock.ExpectExec("DELETE FROM name_enrichment_cache WHERE name = \\$1$").
+		WithArgs("ivan").
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	removed, err := repo.InvalidateCachedEnrichments(context.Background(), "ivan", "")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), removed)
+}
+
+func TestInvalidateCachedEnrichments_SingleProvider(t *testing.T) {
+	repo, mock, close := newTestCacheRepo(t)
+	defer close()
+
+	mock.ExpectExec("DELETE FROM name_enrichment_cache WHERE name = \\$1 AND provider = \\$2").
+		WithArgs("ivan", "agify").
+		WillReturnError(errors.New("delete error"))
+
+	_, err := repo.InvalidateCachedEnrichments(context.Background(), "ivan", "agify")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to invalidate cached enrichments")
+}
@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/adal4ik/people-enrichment-service/internal/models"
+	"github.com/adal4ik/people-enrichment-service/utils"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type JobRepositoryInterface interface {
+	Enqueue(ctx context.Context, personID uuid.UUID) error
+	EnqueuePending(ctx context.Context) (int64, error)
+	Claim(ctx context.Context, visibility time.Duration) (models.EnrichmentJob, bool, error)
+	Complete(ctx context.Context, job models.EnrichmentJob) error
+	Retry(ctx context.Context, job models.EnrichmentJob, delay time.Duration, reason string) error
+	Fail(ctx context.Context, job models.EnrichmentJob, reason string) error
+	Release(ctx context.Context, job models.EnrichmentJob) error
+}
+
+// JobRepository is a durable enrichment queue in the enrichment_jobs table.
+// Jobs are claimed with FOR UPDATE SKIP LOCKED, so any number of replicas can
+// work the queue without handing out a job twice.
+type JobRepository struct {
+	logger *zap.Logger
+	db     *sql.DB
+}
+
+func NewJobRepository(db *sql.DB, logger *zap.Logger) *JobRepository {
+	return &JobRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Enqueue adds a job for personID unless it already has an open one.
+func (j *JobRepository) Enqueue(ctx context.Context, personID uuid.UUID) error {
+	query := `
+		INSERT INTO enrichment_jobs (person_id)
+		VALUES ($1)
+		ON CONFLICT (person_id) WHERE status IN ('pending', 'running') DO NOTHING
+	`
+	j.logger.Debug("executing job insert", zap.String("query", query), zap.Any("person_id", personID))
+
+	if _, err := j.db.ExecContext(ctx, query, personID); err != nil {
+		return fmt.Errorf("failed to enqueue enrichment job: %w", err)
+	}
+	return nil
+}
+
+// EnqueuePending adds a job for every pending person without an open one,
+// e.g. persons stored just before a crash, and returns how many were added.
+func (j *JobRepository) EnqueuePending(ctx context.Context) (int64, error) {
+	query := `
+		INSERT INTO enrichment_jobs (person_id)
+		SELECT id FROM persons WHERE enrichment_status = 'pending'
+		ON CONFLICT (person_id) WHERE status IN ('pending', 'running') DO NOTHING
+	`
+	j.logger.Debug("executing job insert", zap.String("query", query))
+
+	res, err := j.db.ExecContext(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("failed to enqueue pending persons: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	return n, nil
+}
+
+// Claim takes the job that is due first: a pending one whose run_at has
+// passed, or a running one whose worker let the visibility timeout expire.
+// The job is hidden from other workers for visibility and its attempt counted.
+// It returns false when no job is due.
+func (j *JobRepository) Claim(ctx context.Context, visibility time.Duration) (models.EnrichmentJob, bool, error) {
+	query := `
+		UPDATE enrichment_jobs
+		SET status = 'running',
+			attempts = attempts + 1,
+			lease_id = $1,
+			locked_until = now() + make_interval(secs => $2),
+			updated_at = now()
+		WHERE id = (
+			SELECT id FROM enrichment_jobs
+			WHERE (status = 'pending' AND run_at <= now())
+				OR (status = 'running' AND locked_until <= now())
+			ORDER BY run_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, person_id, attempts
+	`
+	job := models.EnrichmentJob{Lease: uuid.New()}
+	err := j.db.QueryRowContext(ctx, query, job.Lease, visibility.Seconds()).Scan(&job.ID, &job.PersonID, &job.Attempts)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.EnrichmentJob{}, false, nil
+	}
+	if err != nil {
+		return models.EnrichmentJob{}, false, fmt.Errorf("failed to claim enrichment job: %w", err)
+	}
+	return job, true, nil
+}
+
+// Complete marks job done.
+func (j *JobRepository) Complete(ctx context.Context, job models.EnrichmentJob) error {
+	query := `
+		UPDATE enrichment_jobs
+		SET status = 'done', last_error = NULL, lease_id = NULL, locked_until = NULL, updated_at = now()
+		WHERE id = $1 AND lease_id = $2
+	`
+	return j.report(ctx, "complete", query, job.ID, job.Lease)
+}
+
+// Retry makes job due again after delay, keeping reason as its last error.
+func (j *JobRepository) Retry(ctx context.Context, job models.EnrichmentJob, delay time.Duration, reason string) error {
+	query := `
+		UPDATE enrichment_jobs
+		SET status = 'pending',
+			last_error = $3,
+			run_at = now() + make_interval(secs => $4),
+			lease_id = NULL,
+			locked_until = NULL,
+			updated_at = now()
+		WHERE id = $1 AND lease_id = $2
+	`
+	return j.report(ctx, "retry", query, job.ID, job.Lease, reason, delay.Seconds())
+}
+
+// Fail gives up on job and marks its person failed if it is still pending.
+func (j *JobRepository) Fail(ctx context.Context, job models.EnrichmentJob, reason string) error {
+	query := `
+		WITH failed AS (
+			UPDATE enrichment_jobs
+			SET status = 'failed', last_error = $3, lease_id = NULL, locked_until = NULL, updated_at = now()
+			WHERE id = $1 AND lease_id = $2
+			RETURNING person_id
+		), person AS (
+			UPDATE persons
+			SET enrichment_status = 'failed', enrichment_error = $3, updated_at = now()
+			WHERE id IN (SELECT person_id FROM failed) AND enrichment_status = 'pending'
+		)
+		SELECT count(*) FROM failed
+	`
+	j.logger.Debug("executing job update", zap.String("query", query), zap.Int64("id", job.ID))
+
+	var n int
+	if err := j.db.QueryRowContext(ctx, query, job.ID, job.Lease, reason).Scan(&n); err != nil {
+		return fmt.Errorf("failed to fail enrichment job: %w", err)
+	}
+	if n == 0 {
+		return utils.ErrJobLeaseLost
+	}
+	return nil
+}
+
+// Release hands job back to the queue without counting the attempt, for
+// work interrupted by a shutdown.
+func (j *JobRepository) Release(ctx context.Context, job models.EnrichmentJob) error {
+	query := `
+		UPDATE enrichment_jobs
+		SET status = 'pending',
+			attempts = attempts - 1,
+			run_at = now(),
+			lease_id = NULL,
+			locked_until = NULL,
+			updated_at = now()
+		WHERE id = $1 AND lease_id = $2
+	`
+	return j.report(ctx, "release", query, job.ID, job.Lease)
+}
+
+// report runs an update of a claimed job and returns ErrJobLeaseLost when the
+// claim no longer holds, i.e. the job was reclaimed or deleted meanwhile.
+func (j *JobRepository) report(ctx context.Context, action, query string, args ...interface{}) error {
+	j.logger.Debug("executing job update", zap.String("query", query), zap.Any("id", args[0]))
+
+	res, err := j.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to %s enrichment job: %w", action, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if n == 0 {
+		return utils.ErrJobLeaseLost
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/adal4ik/people-enrichment-service/internal/models"
+	"github.com/adal4ik/people-enrichment-service/utils"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+)
+
+func newTestJobRepo(t *testing.T) (*JobRepository, sqlmock.Sqlmock, func()) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	return NewJobRepository(db, zaptest.NewLogger(t)), mock, func() { db.Close() }
+}
+
+func TestEnqueue_Success(t *testing.T) {
+	repo, mock, close := newTestJobRepo(t)
+	defer close()
+
+	id := uuid.New()
+	mock.ExpectExec("INSERT INTO enrichment_jobs \\(person_id\\) VALUES \\(\\$1\\) ON CONFLICT").
+		WithArgs(id).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	assert.NoError(t, repo.Enqueue(context.Background(), id))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEnqueuePending_Success(t *testing.T) {
+	repo, mock, close := newTestJobRepo(t)
+	defer close()
+
+	mock.ExpectExec("INSERT INTO enrichment_jobs \\(person_id\\) SELECT id FROM persons WHERE enrichment_status = 'pending'").
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	n, err := repo.EnqueuePending(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), n)
+}
+
+func TestClaim_Success(t *testing.T) {
+	repo, mock, close := newTestJobRepo(t)
+	defer close()
+
+	personID := uuid.New()
+	mock.ExpectQuery("UPDATE enrichment_jobs SET status = 'running'.*FOR UPDATE SKIP LOCKED").
+		WithArgs(sqlmock.AnyArg(), 120.0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "person_id", "attempts"}).AddRow(7, personID, 2))
+
+	job, ok, err := repo.Claim(context.Background(), 2*time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, int64(7), job.ID)
+	assert.Equal(t, personID, job.PersonID)
+	assert.Equal(t, 2, job.Attempts)
+	assert.NotEqual(t, uuid.Nil, job.Lease)
+}
+
+func TestClaim_NoJobDue(t *testing.T) {
+	repo, mock, close := newTestJobRepo(t)
+	defer close()
+
+	mock.ExpectQuery("UPDATE enrichment_jobs").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "person_id", "attempts"}))
+
+	_, ok, err := repo.Claim(context.Background(), time.Minute)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestClaim_Error(t *testing.T) {
+	repo, mock, close := newTestJobRepo(t)
+	defer close()
+
+	mock.ExpectQuery("UPDATE enrichment_jobs").WillReturnError(errors.New("db down"))
+
+	_, _, err := repo.Claim(context.Background(), time.Minute)
+	assert.ErrorContains(t, err, "failed to claim enrichment job")
+}
+
+func TestComplete_LeaseLost(t *testing.T) {
+	repo, mock, close := newTestJobRepo(t)
+	defer close()
+
+	job := models.EnrichmentJob{ID: 7, Lease: uuid.New()}
+	mock.ExpectExec("UPDATE enrichment_jobs SET status = 'done'").
+		WithArgs(job.ID, job.Lease).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.ErrorIs(t, repo.Complete(context.Background(), job), utils.ErrJobLeaseLost)
+}
+
+func TestRetry_Success(t *testing.T) {
+	repo, mock, close := newTestJobRepo(t)
+	defer close()
+
+	job := models.EnrichmentJob{ID: 7, Lease: uuid.New()}
+	mock.ExpectExec("UPDATE enrichment_jobs SET status = 'pending'").
+		WithArgs(job.ID, job.Lease, "agify: down", 30.0).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.NoError(t, repo.Retry(context.Background(), job, 30*time.Second, "agify: down"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFail_MarksPersonFailed(t *testing.T) {
+	repo, mock, close := newTestJobRepo(t)
+	defer close()
+
+	job := models.EnrichmentJob{ID: 7, Lease: uuid.New()}
+	mock.ExpectQuery("WITH failed AS \\( UPDATE enrichment_jobs SET status = 'failed'.*UPDATE persons SET enrichment_status = 'failed'").
+		WithArgs(job.ID, job.Lease, "agify: down").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	assert.NoError(t, repo.Fail(context.Background(), job, "agify: down"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRelease_Success(t *testing.T) {
+	repo, mock, close := newTestJobRepo(t)
+	defer close()
+
+	job := models.EnrichmentJob{ID: 7, Lease: uuid.New()}
+	mock.ExpectExec("UPDATE enrichment_jobs SET status = 'pending', attempts = attempts - 1").
+		WithArgs(job.ID, job.Lease).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.NoError(t, repo.Release(context.Background(), job))
+}
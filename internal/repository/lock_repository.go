@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+type LockRepositoryInterface interface {
+	TryLock(ctx context.Context, key int64) (release func(), acquired bool, err error)
+}
+
+// LockRepository hands out Postgres session-level advisory locks, so that only
+// one replica runs a given job at a time.
+type LockRepository struct {
+	logger *zap.Logger
+	db     *sql.DB
+}
+
+func NewLockRepository(db *sql.DB, logger *zap.Logger) *LockRepository {
+	return &LockRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// TryLock takes the advisory lock key without waiting. The lock belongs to a
+// dedicated connection that is held until release is called.
+func (l *LockRepository) TryLock(ctx context.Context, key int64) (func(), bool, error) {
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get connection: %w", err)
+	}
+
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&acquired); err != nil {
+		conn.Close()
+		return nil, false, fmt.Errorf("failed to take advisory lock: %w", err)
+	}
+	if !acquired {
+		conn.Close()
+		return nil, false, nil
+	}
+
+	release := func() {
+		// The caller's context may already be done; the lock must still be released.
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", key); err != nil {
+			l.logger.Warn("failed to release advisory lock", zap.Int64("key", key), zap.Error(err))
+		}
+		conn.Close()
+	}
+	return release, true, nil
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestTryLock_AcquiredAndReleased(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+	repo := NewLockRepository(db, zaptest.NewLogger(t))
+
+	mock.ExpectQuery("SELECT pg_try_advisory_lock\\(\\$1\\)").
+		WithArgs(int64(42)).
+		WillReturnRows(sqlmock.NewRows([]string{"pg_try_advisory_lock"}).AddRow(true))
+	mock.ExpectExec("SELECT pg_advisory_unlock\\(\\$1\\)").
+		WithArgs(int64(42)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	release, acquired, err := repo.TryLock(context.Background(), 42)
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+	release()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTryLock_HeldElsewhere(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+	repo := NewLockRepository(db, zaptest.NewLogger(t))
+
+	mock.ExpectQuery("SELECT pg_try_advisory_lock").
+		WithArgs(int64(42)).
+		WillReturnRows(sqlmock.NewRows([]string{"pg_try_advisory_lock"}).AddRow(false))
+
+	release, acquired, err := repo.TryLock(context.Background(), 42)
+	assert.NoError(t, err)
+	assert.False(t, acquired)
+	assert.Nil(t, release)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -3,9 +3,11 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/adal4ik/people-enrichment-service/internal/models"
 	"github.com/adal4ik/people-enrichment-service/utils"
@@ -15,10 +17,16 @@ import (
 
 type PersonRepositoryInterface interface {
 	CreatePerson(ctx context.Context, person models.Person) error
-	GetPersons(ctx context.Context, limit, offset, ageMin, ageMax int, name, surname, gender, nationality string) ([]models.Person, error)
+	GetPersons(ctx context.Context, limit, offset, ageMin, ageMax int, name, nameNormalized, surname, gender, nationality string) ([]models.Person, error)
 	GetPerson(ctx context.Context, id uuid.UUID) (models.Person, error)
 	DeletePerson(ctx context.Context, id uuid.UUID) error
 	UpdatePerson(ctx context.Context, person models.Person) error
+	UpdateEnrichment(ctx context.Context, person models.Person) error
+	SaveEnrichments(ctx context.Context, personID uuid.UUID, enrichments map[string]models.Enrichment) error
+	GetEnrichments(ctx context.Context, personID uuid.UUID) (map[string]models.Enrichment, error)
+	GetStalePersonIDs(ctx context.Context, olderThan time.Time, after uuid.UUID, limit int) ([]uuid.UUID, error)
+	GetUnnormalizedPersons(ctx context.Context, limit int) ([]models.Person, error)
+	SetNameNormalized(ctx context.Context, id uuid.UUID, nameNormalized string) error
 }
 
 type PersonRepository struct {
@@ -35,8 +43,8 @@ func NewPersonRepository(db *sql.DB, logger *zap.Logger) *PersonRepository {
 
 func (p *PersonRepository) CreatePerson(ctx context.Context, person models.Person) error {
 	query := `
-		INSERT INTO persons (id, name, surname, patronymic, age, gender, nationality, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7,now(), now())
+		INSERT INTO persons (id, name, surname, patronymic, age, gender, nationality, enrichment_status, enrichment_error, country_hint, name_normalized, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, now(), now())
 	`
 	p.logger.Debug("executing insert query", zap.String("query", query), zap.Any("person", person))
 
@@ -48,6 +56,10 @@ func (p *PersonRepository) CreatePerson(ctx context.Context, person models.Perso
 		person.Age,
 		person.Gender,
 		person.Nationality,
+		enrichmentStatus(person),
+		person.EnrichmentError,
+		person.CountryHint,
+		person.NameNormalized,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to insert person: %w", err)
@@ -56,21 +68,35 @@ func (p *PersonRepository) CreatePerson(ctx context.Context, person models.Perso
 	return nil
 }
 
-func (p *PersonRepository) GetPersons(ctx context.Context, limit, offset, ageMin, ageMax int, name, surname, gender, nationality string) ([]models.Person, error) {
+// GetPersons lists persons matching the given filters. The age bounds apply
+// only when ageMin is positive or ageMax is not negative, so that persons
+// without a known age, e.g. still pending enrichment, are listed otherwise.
+func (p *PersonRepository) GetPersons(ctx context.Context, limit, offset, ageMin, ageMax int, name, nameNormalized, surname, gender, nationality string) ([]models.Person, error) {
 	query := `
-		SELECT id, name, surname, patronymic, age, gender, nationality, created_at, updated_at
+		SELECT id, name, surname, patronymic, age, gender, nationality, created_at, updated_at, enrichment_status, enrichment_error, country_hint, name_normalized
 		FROM persons
-		WHERE age BETWEEN $1 AND $2
+		WHERE true
 	`
 
-	args := []interface{}{ageMin, ageMax}
-	argPos := 3
+	var args []interface{}
+	argPos := 1
 
-	if name != "" {
-		query += fmt.Sprintf(" AND name ILIKE $%d", argPos)
-		args = append(args, "%"+name+"%")
+	if ageMin > 0 {
+		query += fmt.Sprintf(" AND age >= $%d", argPos)
+		args = append(args, ageMin)
+		argPos++
+	}
+	if ageMax >= 0 {
+		query += fmt.Sprintf(" AND age <= $%d", argPos)
+		args = append(args, ageMax)
 		argPos++
 	}
+	if name != "" {
+		// The normalized column also finds Cyrillic names by their Latin spelling.
+		query += fmt.Sprintf(" AND (name ILIKE $%d OR name_normalized ILIKE $%d)", argPos, argPos+1)
+		args = append(args, "%"+name+"%", "%"+nameNormalized+"%")
+		argPos += 2
+	}
 	if surname != "" {
 		query += fmt.Sprintf(" AND surname ILIKE $%d", argPos)
 		args = append(args, "%"+surname+"%")
@@ -109,6 +135,12 @@ func (p *PersonRepository) GetPersons(ctx context.Context, limit, offset, ageMin
 			&person.Age,
 			&person.Gender,
 			&person.Nationality,
+			&person.CreatedAt,
+			&person.UpdatedAt,
+			&person.EnrichmentStatus,
+			&person.EnrichmentError,
+			&person.CountryHint,
+			&person.NameNormalized,
 		); err != nil {
 			return nil, fmt.Errorf("failed to scan person: %w", err)
 		}
@@ -124,7 +156,7 @@ func (p *PersonRepository) GetPersons(ctx context.Context, limit, offset, ageMin
 
 func (p *PersonRepository) GetPerson(ctx context.Context, id uuid.UUID) (models.Person, error) {
 	query := `
-		SELECT id, name, surname, patronymic, age, gender, nationality, created_at, updated_at
+		SELECT id, name, surname, patronymic, age, gender, nationality, created_at, updated_at, enrichment_status, enrichment_error, country_hint, name_normalized
 		FROM persons
 		WHERE id = $1
 	`
@@ -141,6 +173,10 @@ func (p *PersonRepository) GetPerson(ctx context.Context, id uuid.UUID) (models.
 		&person.Nationality,
 		&person.CreatedAt,
 		&person.UpdatedAt,
+		&person.EnrichmentStatus,
+		&person.EnrichmentError,
+		&person.CountryHint,
+		&person.NameNormalized,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -211,6 +247,11 @@ func (p *PersonRepository) UpdatePerson(ctx context.Context, person models.Perso
 		args = append(args, *person.Nationality)
 		i++
 	}
+	if person.NameNormalized != nil {
+		query += fmt.Sprintf("name_normalized = $%d, ", i)
+		args = append(args, *person.NameNormalized)
+		i++
+	}
 
 	query = strings.TrimSuffix(query, ", ")
 	query += fmt.Sprintf(", updated_at = now() WHERE id = $%d", i)
@@ -233,3 +274,245 @@ func (p *PersonRepository) UpdatePerson(ctx context.Context, person models.Perso
 
 	return nil
 }
+
+// UpdateEnrichment stores the enriched attributes and enrichment status of person.
+func (p *PersonRepository) UpdateEnrichment(ctx context.Context, person models.Person) error {
+	query := `
+		UPDATE persons
+		SET age = $1, gender = $2, nationality = $3, enrichment_status = $4, enrichment_error = $5, updated_at = now()
+		WHERE id = $6
+	`
+	p.logger.Debug("executing enrichment update", zap.String("query", query), zap.Any("person", person))
+
+	res, err := p.db.ExecContext(ctx, query,
+		person.Age,
+		person.Gender,
+		person.Nationality,
+		enrichmentStatus(person),
+		person.EnrichmentError,
+		person.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update enrichment: %w", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return utils.ErrPersonNotFound
+	}
+	return nil
+}
+
+func enrichmentStatus(person models.Person) string {
+	if person.EnrichmentStatus == "" {
+		return models.EnrichmentCompleted
+	}
+	return person.EnrichmentStatus
+}
+
+func (p *PersonRepository) SaveEnrichments(ctx context.Context, personID uuid.UUID, enrichments map[string]models.Enrichment) error {
+	query := `
+		INSERT INTO person_enrichments (person_id, attribute, provider, value, probability, sample_count, evidence, strategy, sources, error, low_confidence, fetched_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (person_id, attribute) DO UPDATE SET
+			provider = EXCLUDED.provider,
+			value = EXCLUDED.value,
+			probability = EXCLUDED.probability,
+			sample_count = EXCLUDED.sample_count,
+			evidence = EXCLUDED.evidence,
+			strategy = EXCLUDED.strategy,
+			sources = EXCLUDED.sources,
+			error = EXCLUDED.error,
+			low_confidence = EXCLUDED.low_confidence,
+			fetched_at = EXCLUDED.fetched_at
+	`
+	p.logger.Debug("executing enrichment upsert", zap.String("query", query), zap.Any("id", personID), zap.Int("count", len(enrichments)))
+
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for attribute, e := range enrichments {
+		var evidence, strategy, sources interface{}
+		if len(e.Evidence) > 0 {
+			evidence = []byte(e.Evidence)
+		}
+		if e.Strategy != "" {
+			strategy = e.Strategy
+		}
+		if len(e.Sources) > 0 {
+			data, err := json.Marshal(e.Sources)
+			if err != nil {
+				return fmt.Errorf("failed to encode %s sources: %w", attribute, err)
+			}
+			sources = data
+		}
+		if _, err := tx.ExecContext(ctx, query,
+			personID,
+			attribute,
+			e.Provider,
+			e.Value,
+			e.Probability,
+			e.SampleCount,
+			evidence,
+			strategy,
+			sources,
+			e.Error,
+			e.LowConfidence,
+			e.FetchedAt,
+		); err != nil {
+			return fmt.Errorf("failed to save %s enrichment: %w", attribute, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit enrichments: %w", err)
+	}
+	return nil
+}
+
+func (p *PersonRepository) GetEnrichments(ctx context.Context, personID uuid.UUID) (map[string]models.Enrichment, error) {
+	query := `
+		SELECT attribute, provider, value, probability, sample_count, evidence, strategy, sources, error, low_confidence, fetched_at
+		FROM person_enrichments
+		WHERE person_id = $1
+	`
+	p.logger.Debug("executing select query", zap.String("query", query), zap.Any("id", personID))
+
+	rows, err := p.db.QueryContext(ctx, query, personID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query enrichments: %w", err)
+	}
+	defer rows.Close()
+
+	enrichments := make(map[string]models.Enrichment)
+	for rows.Next() {
+		var (
+			attribute string
+			evidence  []byte
+			strategy  sql.NullString
+			sources   []byte
+			e         models.Enrichment
+		)
+		if err := rows.Scan(
+			&attribute,
+			&e.Provider,
+			&e.Value,
+			&e.Probability,
+			&e.SampleCount,
+			&evidence,
+			&strategy,
+			&sources,
+			&e.Error,
+			&e.LowConfidence,
+			&e.FetchedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan enrichment: %w", err)
+		}
+		if len(evidence) > 0 {
+			e.Evidence = evidence
+		}
+		e.Strategy = strategy.String
+		if len(sources) > 0 {
+			if err := json.Unmarshal(sources, &e.Sources); err != nil {
+				return nil, fmt.Errorf("failed to decode %s sources: %w", attribute, err)
+			}
+		}
+		enrichments[attribute] = e
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return enrichments, nil
+}
+
+// GetStalePersonIDs returns up to limit ids greater than after, in id order, of
+// persons whose oldest enrichment (or last update when there is none) is older
+// than olderThan. Persons still pending enrichment are skipped.
+func (p *PersonRepository) GetStalePersonIDs(ctx context.Context, olderThan time.Time, after uuid.UUID, limit int) ([]uuid.UUID, error) {
+	query := `
+		SELECT p.id
+		FROM persons p
+		LEFT JOIN person_enrichments e ON e.person_id = p.id
+		WHERE p.enrichment_status <> 'pending' AND p.id > $2
+		GROUP BY p.id, p.updated_at
+		HAVING COALESCE(MIN(e.fetched_at), p.updated_at) < $1
+		ORDER BY p.id
+		LIMIT $3
+	`
+	p.logger.Debug("executing stale persons query", zap.String("query", query), zap.Time("older_than", olderThan), zap.Any("after", after))
+
+	rows, err := p.db.QueryContext(ctx, query, olderThan, after, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query stale persons: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan person id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return ids, nil
+}
+
+// GetUnnormalizedPersons returns the id and name of up to limit persons whose
+// normalized name has not been stored yet, e.g. persons created before it was.
+func (p *PersonRepository) GetUnnormalizedPersons(ctx context.Context, limit int) ([]models.Person, error) {
+	query := `
+		SELECT id, name
+		FROM persons
+		WHERE name_normalized IS NULL
+		ORDER BY id
+		LIMIT $1
+	`
+	p.logger.Debug("executing unnormalized persons query", zap.String("query", query), zap.Int("limit", limit))
+
+	rows, err := p.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query unnormalized persons: %w", err)
+	}
+	defer rows.Close()
+
+	var persons []models.Person
+	for rows.Next() {
+		var person models.Person
+		if err := rows.Scan(&person.ID, &person.Name); err != nil {
+			return nil, fmt.Errorf("failed to scan person: %w", err)
+		}
+		persons = append(persons, person)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return persons, nil
+}
+
+// SetNameNormalized stores the normalized name of a person without touching
+// updated_at, as the person itself did not change.
+func (p *PersonRepository) SetNameNormalized(ctx context.Context, id uuid.UUID, nameNormalized string) error {
+	query := `UPDATE persons SET name_normalized = $2 WHERE id = $1`
+	p.logger.Debug("executing update query", zap.String("query", query), zap.Any("id", id))
+
+	if _, err := p.db.ExecContext(ctx, query, id, nameNormalized); err != nil {
+		return fmt.Errorf("failed to set normalized name: %w", err)
+	}
+	return nil
+}
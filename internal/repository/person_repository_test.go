@@ -65,10 +65,10 @@ func TestGetPersons_QueryError(t *testing.T) {
 	repo, mock, close := newTestRepo(t)
 	defer close()
 
-	mock.ExpectQuery("SELECT id, name, surname, patronymic, age, gender, nationality, created_at, updated_at FROM persons").
+	mock.ExpectQuery("SELECT id, name, surname, patronymic, age, gender, nationality, created_at, updated_at, enrichment_status, enrichment_error, country_hint, name_normalized FROM persons").
 		WillReturnError(errors.New("query error"))
 
-	_, err := repo.GetPersons(context.Background(), 10, 0, 0, 100, "", "", "", "")
+	_, err := repo.GetPersons(context.Background(), 10, 0, 0, 100, "", "", "", "", "")
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to query persons")
 }
@@ -77,13 +77,13 @@ func TestGetPersons_ScanError(t *testing.T) {
 	repo, mock, close := newTestRepo(t)
 	defer close()
 
-	rows := sqlmock.NewRows([]string{"id", "name", "surname", "patronymic", "age", "gender", "nationality", "created_at", "updated_at"}).
-		AddRow("bad-uuid", "John", "Doe", nil, 30, "male", "US", time.Now(), time.Now())
+	rows := sqlmock.NewRows([]string{"id", "name", "surname", "patronymic", "age", "gender", "nationality", "created_at", "updated_at", "enrichment_status", "enrichment_error", "country_hint", "name_normalized"}).
+		AddRow("bad-uuid", "John", "Doe", nil, 30, "male", "US", time.Now(), time.Now(), "completed", nil, nil, nil)
 
-	mock.ExpectQuery("SELECT id, name, surname, patronymic, age, gender, nationality, created_at, updated_at FROM persons").
+	mock.ExpectQuery("SELECT id, name, surname, patronymic, age, gender, nationality, created_at, updated_at, enrichment_status, enrichment_error, country_hint, name_normalized FROM persons").
 		WillReturnRows(rows)
 
-	_, err := repo.GetPersons(context.Background(), 10, 0, 0, 100, "", "", "", "")
+	_, err := repo.GetPersons(context.Background(), 10, 0, 0, 100, "", "", "", "", "")
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to scan person")
 }
@@ -93,7 +93,7 @@ func TestGetPerson_NotFound(t *testing.T) {
 	defer close()
 
 	id := uuid.New()
-	mock.ExpectQuery("SELECT id, name, surname, patronymic, age, gender, nationality, created_at, updated_at FROM persons WHERE id = \\$1").
+	mock.ExpectQuery("SELECT id, name, surname, patronymic, age, gender, nationality, created_at, updated_at, enrichment_status, enrichment_error, country_hint, name_normalized FROM persons WHERE id = \\$1").
 		WithArgs(id).
 		WillReturnError(sql.ErrNoRows)
 
@@ -106,10 +106,10 @@ func TestGetPerson_ScanError(t *testing.T) {
 	defer close()
 
 	id := uuid.New()
-	rows := sqlmock.NewRows([]string{"id", "name", "surname", "patronymic", "age", "gender", "nationality", "created_at", "updated_at"}).
-		AddRow("bad-uuid", "John", "Doe", nil, 30, "male", "US", time.Now(), time.Now())
+	rows := sqlmock.NewRows([]string{"id", "name", "surname", "patronymic", "age", "gender", "nationality", "created_at", "updated_at", "enrichment_status", "enrichment_error", "country_hint", "name_normalized"}).
+		AddRow("bad-uuid", "John", "Doe", nil, 30, "male", "US", time.Now(), time.Now(), "completed", nil, nil, nil)
 
-	mock.ExpectQuery("SELECT id, name, surname, patronymic, age, gender, nationality, created_at, updated_at FROM persons WHERE id = \\$1").
+	mock.ExpectQuery("SELECT id, name, surname, patronymic, age, gender, nationality, created_at, updated_at, enrichment_status, enrichment_error, country_hint, name_normalized FROM persons WHERE id = \\$1").
 		WithArgs(id).
 		WillReturnRows(rows)
 
@@ -223,3 +223,257 @@ func TestUpdatePerson_ExecError(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to update person")
 }
+
+func TestSaveEnrichments_Success(t *testing.T) {
+	repo, mock, close := newTestRepo(t)
+	defer close()
+
+	id := uuid.New()
+	value := "male"
+	probability := 0.99
+	fetchedAt := time.Now()
+	enrichments := map[string]models.Enrichment{
+		"gender": {Provider: "genderize", Value: &value, Probability: &probability, FetchedAt: fetchedAt},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO person_enrichments").
+		WithArgs(id, "gender", "genderize", &value, &probability, nil, nil, nil, nil, nil, false, fetchedAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := repo.SaveEnrichments(context.Background(), id, enrichments)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSaveEnrichments_ExecError(t *testing.T) {
+	repo, mock, close := newTestRepo(t)
+	defer close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO person_enrichments").
+		WillReturnError(errors.New("insert error"))
+	mock.ExpectRollback()
+
+	err := repo.SaveEnrichments(context.Background(), uuid.New(), map[string]models.Enrichment{"age": {Provider: "agify"}})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to save age enrichment")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetEnrichments_Success(t *testing.T) {
+	repo, mock, close := newTestRepo(t)
+	defer close()
+
+	id := uuid.New()
+	fetchedAt := time.Now()
+	rows := sqlmock.NewRows([]string{"attribute", "provider", "value", "probability", "sample_count", "evidence", "strategy", "sources", "error", "low_confidence", "fetched_at"}).
+		AddRow("nationality", "nationalize", "RU", 0.42, 1500, []byte(`{"country":[{"country_id":"RU","probability":0.42}]}`), nil, nil, nil, true, fetchedAt)
+
+	mock.ExpectQuery("SELECT attribute, provider, value, probability, sample_count, evidence, strategy, sources, error, low_confidence, fetched_at FROM person_enrichments WHERE person_id = \\$1").
+		WithArgs(id).
+		WillReturnRows(rows)
+
+	enrichments, err := repo.GetEnrichments(context.Background(), id)
+	assert.NoError(t, err)
+	assert.Len(t, enrichments, 1)
+	nationality := enrichments["nationality"]
+	assert.Equal(t, "nationalize", nationality.Provider)
+	assert.Equal(t, "RU", *nationality.Value)
+	assert.Equal(t, 0.42, *nationality.Probability)
+	assert.Equal(t, 1500, *nationality.SampleCount)
+	assert.True(t, nationality.LowConfidence)
+	assert.JSONEq(t, `{"country":[{"country_id":"RU","probability":0.42}]}`, string(nationality.Evidence))
+}
+
+func TestSaveEnrichments_Consensus(t *testing.T) {
+	repo, mock, close := newTestRepo(t)
+	defer close()
+
+	id := uuid.New()
+	value := "female"
+	fetchedAt := time.Now()
+	enrichments := map[string]models.Enrichment{
+		"gender": {Provider: "genderize", Value: &value, Strategy: "weighted-vote", Sources: []string{"genderize", "local"}, FetchedAt: fetchedAt},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO person_enrichments").
+		WithArgs(id, "gender", "genderize", &value, nil, nil, nil, "weighted-vote", []byte(`["genderize","local"]`), nil, false, fetchedAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := repo.SaveEnrichments(context.Background(), id, enrichments)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetEnrichments_Consensus(t *testing.T) {
+	repo, mock, close := newTestRepo(t)
+	defer close()
+
+	id := uuid.New()
+	rows := sqlmock.NewRows([]string{"attribute", "provider", "value", "probability", "sample_count", "evidence", "strategy", "sources", "error", "low_confidence", "fetched_at"}).
+		AddRow("gender", "genderize", "female", 0.8, nil, nil, "weighted-vote", []byte(`["genderize","local"]`), nil, false, time.Now())
+
+	mock.ExpectQuery("SELECT attribute, provider, value, probability, sample_count, evidence, strategy, sources, error, low_confidence, fetched_at FROM person_enrichments").
+		WithArgs(id).
+		WillReturnRows(rows)
+
+	enrichments, err := repo.GetEnrichments(context.Background(), id)
+	assert.NoError(t, err)
+	assert.Equal(t, "weighted-vote", enrichments["gender"].Strategy)
+	assert.Equal(t, []string{"genderize", "local"}, enrichments["gender"].Sources)
+}
+
+func TestGetPersons_Success(t *testing.T) {
+	repo, mock, close := newTestRepo(t)
+	defer close()
+
+	id := uuid.New()
+	rows := sqlmock.NewRows([]string{"id", "name", "surname", "patronymic", "age", "gender", "nationality", "created_at", "updated_at", "enrichment_status", "enrichment_error", "country_hint", "name_normalized"}).
+		AddRow(id, "John", "Doe", nil, 30, "male", "US", time.Now(), time.Now(), "pending", nil, "IT", "john")
+
+	mock.ExpectQuery("SELECT id, name, surname, patronymic, age, gender, nationality, created_at, updated_at, enrichment_status, enrichment_error, country_hint, name_normalized FROM persons").
+		WillReturnRows(rows)
+
+	persons, err := repo.GetPersons(context.Background(), 10, 0, 0, 100, "", "", "", "", "")
+	assert.NoError(t, err)
+	assert.Len(t, persons, 1)
+	assert.Equal(t, id, persons[0].ID)
+	assert.Equal(t, "pending", persons[0].EnrichmentStatus)
+	assert.Equal(t, "IT", *persons[0].CountryHint)
+}
+
+func TestGetPersons_UnfilteredListsUnknownAge(t *testing.T) {
+	repo, mock, close := newTestRepo(t)
+	defer close()
+
+	id := uuid.New()
+	rows := sqlmock.NewRows([]string{"id", "name", "surname", "patronymic", "age", "gender", "nationality", "created_at", "updated_at", "enrichment_status", "enrichment_error", "country_hint", "name_normalized"}).
+		AddRow(id, "John", "Doe", nil, nil, nil, nil, time.Now(), time.Now(), "pending", nil, nil, "john")
+
+	mock.ExpectQuery(`FROM persons WHERE true ORDER BY created_at DESC LIMIT \$1 OFFSET \$2`).
+		WithArgs(10, 0).
+		WillReturnRows(rows)
+
+	persons, err := repo.GetPersons(context.Background(), 10, 0, 0, -1, "", "", "", "", "")
+	assert.NoError(t, err)
+	assert.Len(t, persons, 1)
+	assert.Equal(t, id, persons[0].ID)
+	assert.Nil(t, persons[0].Age)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetPersons_AgeRange(t *testing.T) {
+	repo, mock, close := newTestRepo(t)
+	defer close()
+
+	mock.ExpectQuery(`WHERE true AND age >= \$1 AND age <= \$2 ORDER BY created_at DESC LIMIT \$3 OFFSET \$4`).
+		WithArgs(25, 35, 10, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	_, err := repo.GetPersons(context.Background(), 10, 0, 25, 35, "", "", "", "", "")
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetPersons_NameMatchesNormalizedName(t *testing.T) {
+	repo, mock, close := newTestRepo(t)
+	defer close()
+
+	rows := sqlmock.NewRows([]string{"id", "name", "surname", "patronymic", "age", "gender", "nationality", "created_at", "updated_at", "enrichment_status", "enrichment_error", "country_hint", "name_normalized"}).
+		AddRow(uuid.New(), "Дмитрий", "Иванов", nil, 30, "male", "RU", time.Now(), time.Now(), "completed", nil, nil, "dmitrii")
+
+	mock.ExpectQuery(`WHERE true AND age <= \$1 AND \(name ILIKE \$2 OR name_normalized ILIKE \$3\) ORDER BY created_at DESC LIMIT \$4 OFFSET \$5`).
+		WithArgs(100, "%Dmitrii%", "%dmitrii%", 10, 0).
+		WillReturnRows(rows)
+
+	persons, err := repo.GetPersons(context.Background(), 10, 0, 0, 100, "Dmitrii", "dmitrii", "", "", "")
+	assert.NoError(t, err)
+	assert.Len(t, persons, 1)
+	assert.Equal(t, "dmitrii", *persons[0].NameNormalized)
+}
+
+func TestUpdateEnrichment_Success(t *testing.T) {
+	repo, mock, close := newTestRepo(t)
+	defer close()
+
+	age := 30
+	person := models.Person{ID: uuid.New(), Age: &age, EnrichmentStatus: models.EnrichmentCompleted}
+	mock.ExpectExec("UPDATE persons SET age = \\$1, gender = \\$2, nationality = \\$3, enrichment_status = \\$4, enrichment_error = \\$5, updated_at = now\\(\\) WHERE id = \\$6").
+		WithArgs(&age, nil, nil, "completed", nil, person.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := repo.UpdateEnrichment(context.Background(), person)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateEnrichment_NotFound(t *testing.T) {
+	repo, mock, close := newTestRepo(t)
+	defer close()
+
+	mock.ExpectExec("UPDATE persons SET age").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := repo.UpdateEnrichment(context.Background(), models.Person{ID: uuid.New()})
+	assert.ErrorIs(t, err, utils.ErrPersonNotFound)
+}
+
+func TestGetStalePersonIDs_Success(t *testing.T) {
+	repo, mock, close := newTestRepo(t)
+	defer close()
+
+	olderThan := time.Now().Add(-time.Hour)
+	after := uuid.New()
+	id := uuid.New()
+	mock.ExpectQuery("SELECT p.id FROM persons p LEFT JOIN person_enrichments e").
+		WithArgs(olderThan, after, 50).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(id))
+
+	ids, err := repo.GetStalePersonIDs(context.Background(), olderThan, after, 50)
+	assert.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{id}, ids)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetStalePersonIDs_QueryError(t *testing.T) {
+	repo, mock, close := newTestRepo(t)
+	defer close()
+
+	mock.ExpectQuery("SELECT p.id FROM persons p").
+		WillReturnError(errors.New("query error"))
+
+	_, err := repo.GetStalePersonIDs(context.Background(), time.Now(), uuid.Nil, 50)
+	assert.ErrorContains(t, err, "failed to query stale persons")
+}
+
+func TestGetUnnormalizedPersons_Success(t *testing.T) {
+	repo, mock, close := newTestRepo(t)
+	defer close()
+
+	id := uuid.New()
+	mock.ExpectQuery("SELECT id, name FROM persons WHERE name_normalized IS NULL").
+		WithArgs(100).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(id, "Дмитрий"))
+
+	persons, err := repo.GetUnnormalizedPersons(context.Background(), 100)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Person{{ID: id, Name: "Дмитрий"}}, persons)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSetNameNormalized_Success(t *testing.T) {
+	repo, mock, close := newTestRepo(t)
+	defer close()
+
+	id := uuid.New()
+	mock.ExpectExec("UPDATE persons SET name_normalized = \\$2 WHERE id = \\$1").
+		WithArgs(id, "dmitrii").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.NoError(t, repo.SetNameNormalized(context.Background(), id, "dmitrii"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
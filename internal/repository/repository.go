@@ -8,10 +8,18 @@ import (
 
 type Repository struct {
 	PersonRepository *PersonRepository
+	CacheRepository  *CacheRepository
+	LockRepository   *LockRepository
+	UsageRepository  *UsageRepository
+	JobRepository    *JobRepository
 }
 
 func New(db *sql.DB, logger *zap.Logger) *Repository {
 	return &Repository{
 		PersonRepository: NewPersonRepository(db, logger),
+		CacheRepository:  NewCacheRepository(db, logger),
+		LockRepository:   NewLockRepository(db, logger),
+		UsageRepository:  NewUsageRepository(db, logger),
+		JobRepository:    NewJobRepository(db, logger),
 	}
 }
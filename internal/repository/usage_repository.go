@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/adal4ik/people-enrichment-service/internal/models"
+	"go.uber.org/zap"
+)
+
+type UsageRepositoryInterface interface {
+	AddUsage(ctx context.Context, usage models.ProviderUsage) error
+	GetUsage(ctx context.Context, since time.Time) ([]models.ProviderUsage, error)
+}
+
+// UsageRepository keeps daily request counters per provider and API key.
+type UsageRepository struct {
+	logger *zap.Logger
+	db     *sql.DB
+}
+
+func NewUsageRepository(db *sql.DB, logger *zap.Logger) *UsageRepository {
+	return &UsageRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// AddUsage adds usage.Requests to the counter of its provider, key and day.
+func (u *UsageRepository) AddUsage(ctx context.Context, usage models.ProviderUsage) error {
+	query := `
+		INSERT INTO provider_usage (provider, key_id, day, requests)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (provider, key_id, day) DO UPDATE SET
+			requests = provider_usage.requests + EXCLUDED.requests
+	`
+	u.logger.Debug("executing usage upsert", zap.String("query", query), zap.Any("usage", usage))
+
+	if _, err := u.db.ExecContext(ctx, query, usage.Provider, usage.KeyID, usage.Day, usage.Requests); err != nil {
+		return fmt.Errorf("failed to save provider usage: %w", err)
+	}
+	return nil
+}
+
+// GetUsage returns the counters of every day from since on, newest first.
+func (u *UsageRepository) GetUsage(ctx context.Context, since time.Time) ([]models.ProviderUsage, error) {
+	query := `
+		SELECT provider, key_id, day, requests
+		FROM provider_usage
+		WHERE day >= $1
+		ORDER BY day DESC, provider, key_id
+	`
+	u.logger.Debug("executing select query", zap.String("query", query), zap.Time("since", since))
+
+	rows, err := u.db.QueryContext(ctx, query, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query provider usage: %w", err)
+	}
+	defer rows.Close()
+
+	var usage []models.ProviderUsage
+	for rows.Next() {
+		var item models.ProviderUsage
+		if err := rows.Scan(&item.Provider, &item.KeyID, &item.Day, &item.Requests); err != nil {
+			return nil, fmt.Errorf("failed to scan provider usage: %w", err)
+		}
+		usage = append(usage, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	return usage, nil
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/adal4ik/people-enrichment-service/internal/models"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+)
+
+func newTestUsageRepo(t *testing.T) (*UsageRepository, sqlmock.Sqlmock, func()) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	return NewUsageRepository(db, zaptest.NewLogger(t)), mock, func() { db.Close() }
+}
+
+func TestAddUsage_Success(t *testing.T) {
+	repo, mock, close := newTestUsageRepo(t)
+	defer close()
+
+	day := time.Date(2025, 6, 19, 0, 0, 0, 0, time.UTC)
+	mock.ExpectExec("INSERT INTO provider_usage").
+		WithArgs("genderize", "1a2b3c4d", day, int64(12)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := repo.AddUsage(context.Background(), models.ProviderUsage{Provider: "genderize", KeyID: "1a2b3c4d", Day: day, Requests: 12})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAddUsage_Error(t *testing.T) {
+	repo, mock, close := newTestUsageRepo(t)
+	defer close()
+
+	mock.ExpectExec("INSERT INTO provider_usage").WillReturnError(errors.New("db down"))
+
+	err := repo.AddUsage(context.Background(), models.ProviderUsage{Provider: "agify", Requests: 1})
+	assert.ErrorContains(t, err, "failed to save provider usage")
+}
+
+func TestGetUsage_Success(t *testing.T) {
+	repo, mock, close := newTestUsageRepo(t)
+	defer close()
+
+	since := time.Date(2025, 6, 13, 0, 0, 0, 0, time.UTC)
+	day := since.AddDate(0, 0, 6)
+	rows := sqlmock.NewRows([]string{"provider", "key_id", "day", "requests"}).
+		AddRow("genderize", "1a2b3c4d", day, 840).
+		AddRow("agify", "", day, 12)
+	mock.ExpectQuery("SELECT provider, key_id, day, requests FROM provider_usage WHERE day >= \\$1").
+		WithArgs(since).
+		WillReturnRows(rows)
+
+	usage, err := repo.GetUsage(context.Background(), since)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.ProviderUsage{
+		{Provider: "genderize", KeyID: "1a2b3c4d", Day: day, Requests: 840},
+		{Provider: "agify", Day: day, Requests: 12},
+	}, usage)
+}
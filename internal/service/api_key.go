@@ -0,0 +1,77 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/adal4ik/people-enrichment-service/internal/config"
+)
+
+// redacted replaces API keys in anything that may end up in logs or responses.
+const redacted = "REDACTED"
+
+// ProviderAPIKey returns the API key configured for provider, falling back to
+// the shared PROVIDER_API_KEY.
+func ProviderAPIKey(cfg config.Config, provider string) string {
+	var key string
+	switch provider {
+	case "agify":
+		key = cfg.AgifyAPIKey
+	case "genderize":
+		key = cfg.GenderizeAPIKey
+	case "nationalize":
+		key = cfg.NationalizeAPIKey
+	}
+	if key == "" {
+		key = cfg.ProviderAPIKey
+	}
+	return key
+}
+
+// KeyID is a short fingerprint that tells API keys apart without revealing
+// them. It is empty when there is no key.
+func KeyID(key string) string {
+	if key == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:4])
+}
+
+// APIKeyClient adds an API key query parameter to every request and keeps the
+// key out of the errors it returns.
+type APIKeyClient struct {
+	client HTTPDoer
+	param  string
+	key    string
+}
+
+func NewAPIKeyClient(client HTTPDoer, param, key string) *APIKeyClient {
+	return &APIKeyClient{client: client, param: param, key: key}
+}
+
+func (c *APIKeyClient) Do(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	query := req.URL.Query()
+	query.Set(c.param, c.key)
+	req.URL.RawQuery = query.Encode()
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		// Transport errors quote the full URL, key included.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			urlErr.URL = c.redact(urlErr.URL)
+		}
+	}
+	return resp, err
+}
+
+func (c *APIKeyClient) redact(s string) string {
+	s = strings.ReplaceAll(s, url.QueryEscape(c.key), redacted)
+	return strings.ReplaceAll(s, c.key, redacted)
+}
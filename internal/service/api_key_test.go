@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/adal4ik/people-enrichment-service/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProviderAPIKey(t *testing.T) {
+	cfg := config.Config{ProviderAPIKey: "shared", GenderizeAPIKey: "gender-key"}
+	assert.Equal(t, "gender-key", ProviderAPIKey(cfg, "genderize"))
+	assert.Equal(t, "shared", ProviderAPIKey(cfg, "agify"))
+	assert.Equal(t, "", ProviderAPIKey(config.Config{}, "nationalize"))
+}
+
+func TestKeyID(t *testing.T) {
+	assert.Equal(t, "", KeyID(""))
+	assert.Len(t, KeyID("secret"), 8)
+	assert.NotContains(t, KeyID("secret"), "secret")
+	assert.NotEqual(t, KeyID("secret"), KeyID("other"))
+}
+
+func TestAPIKeyClient_AddsKey(t *testing.T) {
+	var query url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		w.Write([]byte(`{"name":"anna","age":41,"count":10}`))
+	}))
+	defer srv.Close()
+
+	client := NewAPIKeyClient(http.DefaultClient, "apikey", "secret")
+	e, err := newAgifyEnricher(AttributeAge, config.Config{APIAgeURL: srv.URL}, client, nil)
+	require.NoError(t, err)
+
+	res, err := e.Enrich(context.Background(), Query{Name: "anna"})
+	require.NoError(t, err)
+	assert.Equal(t, "41", res.Value)
+	assert.Equal(t, "secret", query.Get("apikey"))
+	assert.Equal(t, "anna", query.Get("name"))
+}
+
+func TestAPIKeyClient_RedactsKeyFromErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.Close()
+
+	client := NewAPIKeyClient(http.DefaultClient, "apikey", "s3cret/key")
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"?name=anna", nil)
+	require.NoError(t, err)
+
+	_, err = client.Do(req)
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "s3cret")
+	assert.Contains(t, err.Error(), "apikey=REDACTED")
+	assert.Empty(t, req.URL.Query().Get("apikey"), "the caller's request is left alone")
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/adal4ik/people-enrichment-service/internal/models"
+	"go.uber.org/zap"
+)
+
+// BatchEnrichment is the outcome of enriching one query of a batch.
+// Results holds the attributes that succeeded and Errors the ones that did not.
+type BatchEnrichment struct {
+	Query   Query
+	Results []Result
+	Errors  map[Attribute]error
+}
+
+// Err joins the per-attribute failures of the query, or returns nil.
+func (b BatchEnrichment) Err() error {
+	return joinFailures(b.Errors)
+}
+
+// EnrichBatch enriches many queries at once. Every attribute is looked up
+// concurrently and providers that support it receive multi-name requests.
+func (p *PersonService) EnrichBatch(ctx context.Context, qs []Query) []BatchEnrichment {
+	if p.cfg.EnrichTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.cfg.EnrichTimeout)
+		defer cancel()
+	}
+
+	out := make([]BatchEnrichment, len(qs))
+	for i, q := range qs {
+		out[i] = BatchEnrichment{Query: q, Errors: make(map[Attribute]error)}
+	}
+
+	attrs := p.registry.Attributes()
+	qs = slices.Clone(qs)
+	if p.cfg.EnrichLocalize && slices.Contains(attrs, AttributeNationality) && slices.ContainsFunc(attrs, localized) {
+		// Look nationality up first so age and gender can be localized to it.
+		p.enrichBatchAttributes(ctx, []Attribute{AttributeNationality}, qs, out)
+		attrs = slices.DeleteFunc(slices.Clone(attrs), func(attr Attribute) bool {
+			return attr == AttributeNationality
+		})
+		for i := range qs {
+			if qs[i].CountryID == "" {
+				qs[i].CountryID = countryOf(out[i].Results)
+			}
+		}
+	}
+	p.enrichBatchAttributes(ctx, attrs, qs, out)
+	return out
+}
+
+// enrichBatchAttributes looks attrs up concurrently for every query and adds
+// the outcomes to out.
+func (p *PersonService) enrichBatchAttributes(ctx context.Context, attrs []Attribute, qs []Query, out []BatchEnrichment) {
+	results := make([][]Result, len(attrs))
+	errs := make([][]error, len(attrs))
+
+	var wg sync.WaitGroup
+	for i, attr := range attrs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			aqs := make([]Query, len(qs))
+			for j, q := range qs {
+				aqs[j] = q.forAttribute(attr)
+			}
+			results[i], errs[i] = p.registry.EnrichBatch(ctx, attr, aqs)
+		}()
+	}
+	wg.Wait()
+
+	for a, attr := range attrs {
+		for i := range qs {
+			if err := errs[a][i]; err != nil {
+				out[i].Errors[attr] = err
+				continue
+			}
+			out[i].Results = append(out[i].Results, results[a][i])
+		}
+	}
+}
+
+// CreatePersons enriches and stores several persons with batched provider
+// calls. In async and queue mode the persons are stored as pending and
+// enriched in the background instead, like CreatePerson does. The returned
+// errors are aligned with persons; nil means created, in which case the entry
+// is replaced by the stored person.
+func (p *PersonService) CreatePersons(ctx context.Context, persons []models.Person) []error {
+	errs := make([]error, len(persons))
+	if p.pool != nil {
+		for i := range persons {
+			p.normalize(&persons[i])
+			created, err := p.createPending(ctx, persons[i])
+			if err != nil {
+				p.logger.Error("failed to create person in batch", zap.String("name", persons[i].Name), zap.Error(err))
+				errs[i] = err
+				continue
+			}
+			persons[i] = created
+		}
+		return errs
+	}
+
+	qs := make([]Query, len(persons))
+	for i := range persons {
+		p.normalize(&persons[i])
+		qs[i] = p.queryFor(persons[i])
+	}
+	enriched := p.EnrichBatch(ctx, qs)
+
+	for i, person := range persons {
+		saved, err := p.save(ctx, person, enriched[i].Results, enriched[i].Errors)
+		if err != nil {
+			p.logger.Error("failed to create person in batch", zap.String("name", person.Name), zap.Error(err))
+			errs[i] = err
+			continue
+		}
+		persons[i] = saved
+	}
+	return errs
+}
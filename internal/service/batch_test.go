@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/adal4ik/people-enrichment-service/internal/config"
+	"github.com/adal4ik/people-enrichment-service/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+// batchAgifyServer answers multi-name agify requests with age = len(name).
+func batchAgifyServer(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		names := r.URL.Query()["name[]"]
+		assert.LessOrEqual(t, len(names), maxBatchNames)
+		items := make([]map[string]interface{}, len(names))
+		for i, name := range names {
+			items[i] = map[string]interface{}{"name": name, "age": len(name), "count": 10}
+		}
+		json.NewEncoder(w).Encode(items)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func TestRemoteProvider_EnrichBatch(t *testing.T) {
+	srv, calls := batchAgifyServer(t)
+	e, err := newAgifyEnricher(AttributeAge, config.Config{APIAgeURL: srv.URL}, http.DefaultClient, zap.NewNop())
+	assert.NoError(t, err)
+
+	var qs []Query
+	for i := 0; i < 15; i++ {
+		qs = append(qs, Query{Name: fmt.Sprintf("name%02d", i)})
+	}
+	qs = append(qs, Query{Name: "Ann"}, Query{Name: "Ann"})
+
+	results, errs := e.(BatchEnricher).EnrichBatch(context.Background(), qs)
+	assert.Len(t, results, len(qs))
+	for i, err := range errs {
+		assert.NoError(t, err)
+		assert.Equal(t, fmt.Sprint(len(qs[i].Name)), results[i].Value)
+		assert.Equal(t, "agify", results[i].Provider)
+		assert.Equal(t, ptr(10), results[i].SampleCount)
+	}
+	// 16 distinct names fit into two requests of at most ten.
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestRemoteProvider_EnrichBatchRequestError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+	e := &remoteProvider{name: "agify", attr: AttributeAge, baseURL: srv.URL, client: http.DefaultClient, parse: parseAgify}
+
+	_, errs := e.EnrichBatch(context.Background(), []Query{{Name: "Ann"}, {Name: "Bob"}})
+	for _, err := range errs {
+		assert.ErrorContains(t, err, "status 502")
+	}
+}
+
+func TestRegistry_EnrichBatchFallsBackPerQuery(t *testing.T) {
+	r := NewRegistry()
+	r.Register(&stubEnricher{provider: "first", attr: AttributeGender, err: errors.New("down")})
+	r.Register(&stubEnricher{provider: "second", attr: AttributeGender, value: "female"})
+
+	results, errs := r.EnrichBatch(context.Background(), AttributeGender, []Query{{Name: "Anna"}, {Name: "Olga"}})
+	for i := range results {
+		assert.NoError(t, errs[i])
+		assert.Equal(t, "second", results[i].Provider)
+	}
+}
+
+func TestCachedEnricher_EnrichBatchOnlyFetchesMisses(t *testing.T) {
+	srv, calls := batchAgifyServer(t)
+	inner := &remoteProvider{name: "agify", attr: AttributeAge, baseURL: srv.URL, client: http.DefaultClient, parse: parseAgify}
+	cache := NewResultCache(10, time.Hour)
+	e := WithCache(cache)(inner).(BatchEnricher)
+
+	_, errs := e.EnrichBatch(context.Background(), []Query{{Name: "Ann"}})
+	assert.NoError(t, errs[0])
+
+	results, errs := e.EnrichBatch(context.Background(), []Query{{Name: "ann"}, {Name: "Bob"}})
+	assert.NoError(t, errs[0])
+	assert.NoError(t, errs[1])
+	assert.Equal(t, "3", results[0].Value)
+	assert.Equal(t, "3", results[1].Value)
+	assert.Equal(t, int32(2), calls.Load())
+	assert.Equal(t, uint64(1), cache.Stats().Hits)
+}
+
+func TestCreatePersons_ReportsPerPersonErrors(t *testing.T) {
+	repo := new(mockPersonRepo)
+	svc := newEnrichingService(repo, config.Config{},
+		&stubEnricher{provider: "agify", attr: AttributeAge, value: "30"},
+		&nameFailingEnricher{provider: "genderize", attr: AttributeGender, value: "male", fail: "bad"},
+	)
+
+	repo.On("CreatePerson", mock.Anything, mock.MatchedBy(func(p models.Person) bool { return p.Name == "Good" })).Return(nil)
+	repo.On("SaveEnrichments", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	errs := svc.CreatePersons(context.Background(), []models.Person{{Name: "Good"}, {Name: "Bad"}})
+	assert.NoError(t, errs[0])
+	assert.ErrorContains(t, errs[1], "failed to enrich gender")
+	repo.AssertNumberOfCalls(t, "CreatePerson", 1)
+}
+
+// recordingQueue accepts every submission and remembers it.
+type recordingQueue struct{ ids []uuid.UUID }
+
+func (q *recordingQueue) Submit(ctx context.Context, id uuid.UUID) error {
+	q.ids = append(q.ids, id)
+	return nil
+}
+
+func TestCreatePersons_AsyncStoresPending(t *testing.T) {
+	repo := new(mockPersonRepo)
+	age := &countingEnricher{stubEnricher: stubEnricher{provider: "agify", attr: AttributeAge, value: "30"}}
+	svc := newEnrichingService(repo, config.Config{}, age)
+	queue := &recordingQueue{}
+	svc.pool = queue
+
+	first, second := uuid.New(), uuid.New()
+	repo.On("CreatePerson", mock.Anything, mock.MatchedBy(func(p models.Person) bool {
+		return p.EnrichmentStatus == models.EnrichmentPending && p.Age == nil
+	})).Return(nil).Twice()
+
+	persons := []models.Person{{ID: first, Name: "John"}, {ID: second, Name: "Anna"}}
+	errs := svc.CreatePersons(context.Background(), persons)
+	assert.Equal(t, []error{nil, nil}, errs)
+	assert.Equal(t, []uuid.UUID{first, second}, queue.ids)
+	assert.Equal(t, models.EnrichmentPending, persons[1].EnrichmentStatus)
+	assert.Equal(t, 0, age.calls)
+	repo.AssertExpectations(t)
+}
+
+// nameFailingEnricher fails only for the configured name and does not know
+// the unknown one.
+type nameFailingEnricher struct {
+	provider string
+	attr     Attribute
+	value    string
+	fail     string
+	unknown  string
+}
+
+func (n *nameFailingEnricher) Provider() string     { return n.provider }
+func (n *nameFailingEnricher) Attribute() Attribute { return n.attr }
+
+func (n *nameFailingEnricher) Enrich(ctx context.Context, q Query) (Result, error) {
+	if q.Name == n.fail {
+		return Result{}, errors.New("unknown name")
+	}
+	if q.Name == n.unknown {
+		return Result{Attribute: n.attr, Provider: n.provider}, nil
+	}
+	return Result{Attribute: n.attr, Provider: n.provider, Value: n.value}, nil
+}
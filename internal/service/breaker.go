@@ -0,0 +1,223 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/adal4ik/people-enrichment-service/utils"
+	"go.uber.org/zap"
+)
+
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"
+	BreakerOpen     BreakerState = "open"
+	BreakerHalfOpen BreakerState = "half-open"
+)
+
+// BreakerStatus is a snapshot of a provider circuit breaker.
+type BreakerStatus struct {
+	Provider            string       `json:"provider"`
+	State               BreakerState `json:"state"`
+	ConsecutiveFailures int          `json:"consecutive_failures"`
+	OpenedAt            *time.Time   `json:"opened_at,omitempty"`
+}
+
+// CircuitBreaker stops calling a provider after threshold consecutive failures
+// and lets a single trial request through once cooldown has passed.
+type CircuitBreaker struct {
+	mu        sync.Mutex
+	provider  string
+	threshold int
+	cooldown  time.Duration
+	logger    *zap.Logger
+	now       func() time.Time
+
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	trial    bool
+}
+
+func NewCircuitBreaker(provider string, threshold int, cooldown time.Duration, logger *zap.Logger) *CircuitBreaker {
+	return &CircuitBreaker{
+		provider:  provider,
+		threshold: threshold,
+		cooldown:  cooldown,
+		logger:    logger,
+		now:       time.Now,
+		state:     BreakerClosed,
+	}
+}
+
+// Allow reports whether a call may go out. It returns utils.ErrCircuitOpen while
+// the breaker is open or a half-open trial is already in flight.
+func (b *CircuitBreaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			return fmt.Errorf("%s: %w", b.provider, utils.ErrCircuitOpen)
+		}
+		b.transition(BreakerHalfOpen)
+		b.trial = true
+		return nil
+	case BreakerHalfOpen:
+		if b.trial {
+			return fmt.Errorf("%s: %w", b.provider, utils.ErrCircuitOpen)
+		}
+		b.trial = true
+	}
+	return nil
+}
+
+// Record feeds the outcome of an allowed call back into the breaker. A success
+// reported while the breaker is open comes from a call allowed before it
+// opened and does not close it; only the half-open trial can.
+func (b *CircuitBreaker) Record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trial = false
+	if err == nil {
+		if b.state == BreakerOpen {
+			return
+		}
+		b.failures = 0
+		if b.state != BreakerClosed {
+			b.transition(BreakerClosed)
+		}
+		return
+	}
+
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= b.threshold {
+		b.openedAt = b.now()
+		if b.state != BreakerOpen {
+			b.transition(BreakerOpen)
+		}
+	}
+}
+
+// Release gives up an allowed call without recording an outcome.
+func (b *CircuitBreaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trial = false
+}
+
+func (b *CircuitBreaker) Status() BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := BreakerStatus{
+		Provider:            b.provider,
+		State:               b.state,
+		ConsecutiveFailures: b.failures,
+	}
+	if b.state != BreakerClosed {
+		openedAt := b.openedAt
+		status.OpenedAt = &openedAt
+	}
+	return status
+}
+
+func (b *CircuitBreaker) transition(to BreakerState) {
+	b.logger.Warn("circuit breaker state changed",
+		zap.String("provider", b.provider),
+		zap.String("from", string(b.state)),
+		zap.String("to", string(to)),
+		zap.Int("consecutive_failures", b.failures),
+	)
+	b.state = to
+}
+
+// BreakerSet holds one circuit breaker per provider.
+type BreakerSet struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	logger    *zap.Logger
+	breakers  map[string]*CircuitBreaker
+}
+
+func NewBreakerSet(threshold int, cooldown time.Duration, logger *zap.Logger) *BreakerSet {
+	return &BreakerSet{
+		threshold: threshold,
+		cooldown:  cooldown,
+		logger:    logger,
+		breakers:  make(map[string]*CircuitBreaker),
+	}
+}
+
+func (s *BreakerSet) For(provider string) *CircuitBreaker {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.breakers[provider]
+	if !ok {
+		b = NewCircuitBreaker(provider, s.threshold, s.cooldown, s.logger)
+		s.breakers[provider] = b
+	}
+	return b
+}
+
+type breakerEnricher struct {
+	Enricher
+	breaker *CircuitBreaker
+}
+
+// WithCircuitBreaker returns a middleware that guards each provider with its breaker from set.
+func WithCircuitBreaker(set *BreakerSet) EnricherMiddleware {
+	return func(e Enricher) Enricher {
+		return &breakerEnricher{Enricher: e, breaker: set.For(e.Provider())}
+	}
+}
+
+func (b *breakerEnricher) Enrich(ctx context.Context, q Query) (Result, error) {
+	if err := b.breaker.Allow(); err != nil {
+		return Result{}, err
+	}
+	res, err := b.Enricher.Enrich(ctx, q)
+	if err != nil && errors.Is(err, context.Canceled) {
+		// The caller gave up, which says nothing about the provider.
+		b.breaker.Release()
+		return Result{}, err
+	}
+	b.breaker.Record(err)
+	return res, err
+}
+
+func (b *breakerEnricher) EnrichBatch(ctx context.Context, qs []Query) ([]Result, []error) {
+	if err := b.breaker.Allow(); err != nil {
+		errs := make([]error, len(qs))
+		for i := range errs {
+			errs[i] = err
+		}
+		return make([]Result, len(qs)), errs
+	}
+	results, errs := enrichBatch(ctx, b.Enricher, qs)
+
+	// A batch counts as a failure only when no query got an answer.
+	var outcome error
+	for _, err := range errs {
+		if err == nil {
+			outcome = nil
+			break
+		}
+		outcome = err
+	}
+	if outcome != nil && errors.Is(outcome, context.Canceled) {
+		b.breaker.Release()
+		return results, errs
+	}
+	b.breaker.Record(outcome)
+	return results, errs
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/adal4ik/people-enrichment-service/utils"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestCircuitBreaker_OpensAfterThreshold(t *testing.T) {
+	b := NewCircuitBreaker("nationalize", 2, time.Minute, zap.NewNop())
+
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, b.Allow())
+		b.Record(errors.New("timeout"))
+	}
+
+	assert.Equal(t, BreakerOpen, b.Status().State)
+	assert.ErrorIs(t, b.Allow(), utils.ErrCircuitOpen)
+}
+
+func TestCircuitBreaker_HalfOpenTrial(t *testing.T) {
+	now := time.Now()
+	b := NewCircuitBreaker("nationalize", 1, time.Minute, zap.NewNop())
+	b.now = func() time.Time { return now }
+
+	assert.NoError(t, b.Allow())
+	b.Record(errors.New("timeout"))
+	assert.ErrorIs(t, b.Allow(), utils.ErrCircuitOpen)
+
+	now = now.Add(time.Minute)
+	assert.NoError(t, b.Allow())
+	assert.Equal(t, BreakerHalfOpen, b.Status().State)
+	// Only one trial request is let through while half-open.
+	assert.ErrorIs(t, b.Allow(), utils.ErrCircuitOpen)
+
+	b.Record(errors.New("still down"))
+	assert.Equal(t, BreakerOpen, b.Status().State)
+
+	now = now.Add(time.Minute)
+	assert.NoError(t, b.Allow())
+	b.Record(nil)
+	assert.Equal(t, BreakerClosed, b.Status().State)
+	assert.Equal(t, 0, b.Status().ConsecutiveFailures)
+}
+
+func TestCircuitBreaker_LateSuccessKeepsItOpen(t *testing.T) {
+	b := NewCircuitBreaker("nationalize", 1, time.Minute, zap.NewNop())
+
+	// Both calls go out before the breaker opens; the slow one succeeds last.
+	assert.NoError(t, b.Allow())
+	assert.NoError(t, b.Allow())
+	b.Record(errors.New("timeout"))
+	b.Record(nil)
+
+	assert.Equal(t, BreakerOpen, b.Status().State)
+	assert.ErrorIs(t, b.Allow(), utils.ErrCircuitOpen)
+}
+
+func TestBreakerEnricher_FailsFastWhenOpen(t *testing.T) {
+	set := NewBreakerSet(1, time.Minute, zap.NewNop())
+	inner := &countingEnricher{stubEnricher: stubEnricher{provider: "nationalize", attr: AttributeNationality, err: errors.New("down")}}
+	e := WithCircuitBreaker(set)(inner)
+
+	_, err := e.Enrich(context.Background(), Query{Name: "Ivan"})
+	assert.Error(t, err)
+	_, err = e.Enrich(context.Background(), Query{Name: "Ivan"})
+	assert.ErrorIs(t, err, utils.ErrCircuitOpen)
+	assert.Equal(t, 1, inner.calls)
+
+	assert.Equal(t, BreakerOpen, set.For("nationalize").Status().State)
+}
+
+func TestBreakerEnricher_IgnoresCallerCancellation(t *testing.T) {
+	set := NewBreakerSet(1, time.Minute, zap.NewNop())
+	e := WithCircuitBreaker(set)(&blockingEnricher{provider: "agify", attr: AttributeAge})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := e.Enrich(ctx, Query{Name: "Ivan"})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, BreakerClosed, set.For("agify").Status().State)
+}
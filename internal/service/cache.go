@@ -0,0 +1,214 @@
+package service
+
+import (
+	"container/list"
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CacheStats is a snapshot of the cache counters.
+type CacheStats struct {
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Evictions uint64 `json:"evictions"`
+	Size      int    `json:"size"`
+	MaxSize   int    `json:"max_size"`
+}
+
+type cacheEntry struct {
+	key       string
+	result    Result
+	expiresAt time.Time
+}
+
+// ResultCache is a bounded in-memory LRU cache of provider results with a TTL.
+type ResultCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	maxSize int
+	items   map[string]*list.Element
+	order   *list.List
+	now     func() time.Time
+
+	hits      uint64
+	misses    uint64
+	evictions uint64
+}
+
+func NewResultCache(maxSize int, ttl time.Duration) *ResultCache {
+	return &ResultCache{
+		ttl:     ttl,
+		maxSize: maxSize,
+		items:   make(map[string]*list.Element),
+		order:   list.New(),
+		now:     time.Now,
+	}
+}
+
+func (c *ResultCache) Get(key string) (Result, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		c.misses++
+		return Result{}, false
+	}
+	entry := el.Value.(*cacheEntry)
+	if c.now().After(entry.expiresAt) {
+		c.removeElement(el)
+		c.misses++
+		return Result{}, false
+	}
+	c.order.MoveToFront(el)
+	c.hits++
+	return entry.result, true
+}
+
+func (c *ResultCache) Set(key string, result Result) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := c.now().Add(c.ttl)
+	if el, ok := c.items[key]; ok {
+		entry := el.Value.(*cacheEntry)
+		entry.result = result
+		entry.expiresAt = expiresAt
+		c.order.MoveToFront(el)
+		return
+	}
+	c.items[key] = c.order.PushFront(&cacheEntry{key: key, result: result, expiresAt: expiresAt})
+	for c.order.Len() > c.maxSize {
+		c.removeElement(c.order.Back())
+		c.evictions++
+	}
+}
+
+func (c *ResultCache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.removeElement(el)
+	}
+}
+
+// DeletePrefix removes every entry whose key starts with prefix.
+func (c *ResultCache) DeletePrefix(prefix string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, el := range c.items {
+		if strings.HasPrefix(key, prefix) {
+			c.removeElement(el)
+		}
+	}
+}
+
+func (c *ResultCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return CacheStats{
+		Hits:      c.hits,
+		Misses:    c.misses,
+		Evictions: c.evictions,
+		Size:      c.order.Len(),
+		MaxSize:   c.maxSize,
+	}
+}
+
+func (c *ResultCache) removeElement(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.items, el.Value.(*cacheEntry).key)
+}
+
+// normalizeName folds a name into the form used for cache keys.
+func normalizeName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+func cacheKey(provider string, q Query) string {
+	key := provider + ":" + normalizeName(q.Name)
+	if q.CountryID != "" {
+		key += ":" + q.CountryID
+	}
+	return key
+}
+
+type bypassCacheKey struct{}
+
+// withoutCache marks ctx so that cache layers skip reads and always ask the
+// provider; fresh answers are still written back.
+func withoutCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bypassCacheKey{}, true)
+}
+
+func cacheBypassed(ctx context.Context) bool {
+	bypass, _ := ctx.Value(bypassCacheKey{}).(bool)
+	return bypass
+}
+
+// cachedEnricher answers from the cache and stores successful provider results in it.
+type cachedEnricher struct {
+	Enricher
+	cache *ResultCache
+}
+
+// WithCache returns a middleware that caches results of every wrapped enricher.
+func WithCache(cache *ResultCache) EnricherMiddleware {
+	return func(e Enricher) Enricher {
+		return &cachedEnricher{Enricher: e, cache: cache}
+	}
+}
+
+func (c *cachedEnricher) Enrich(ctx context.Context, q Query) (Result, error) {
+	key := cacheKey(c.Provider(), q)
+	if !cacheBypassed(ctx) {
+		if res, ok := c.cache.Get(key); ok {
+			return res, nil
+		}
+	}
+	res, err := c.Enricher.Enrich(ctx, q)
+	if err != nil {
+		return Result{}, err
+	}
+	c.cache.Set(key, res)
+	return res, nil
+}
+
+func (c *cachedEnricher) EnrichBatch(ctx context.Context, qs []Query) ([]Result, []error) {
+	results := make([]Result, len(qs))
+	errs := make([]error, len(qs))
+
+	var (
+		misses []Query
+		index  []int
+	)
+	for i, q := range qs {
+		if cacheBypassed(ctx) {
+			misses, index = append(misses, q), append(index, i)
+			continue
+		}
+		if res, ok := c.cache.Get(cacheKey(c.Provider(), q)); ok {
+			results[i] = res
+			continue
+		}
+		misses = append(misses, q)
+		index = append(index, i)
+	}
+	if len(misses) == 0 {
+		return results, errs
+	}
+
+	missResults, missErrs := enrichBatch(ctx, c.Enricher, misses)
+	for j, i := range index {
+		results[i], errs[i] = missResults[j], missErrs[j]
+		if missErrs[j] == nil {
+			c.cache.Set(cacheKey(c.Provider(), misses[j]), missResults[j])
+		}
+	}
+	return results, errs
+}
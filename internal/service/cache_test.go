@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type countingEnricher struct {
+	stubEnricher
+	calls int
+}
+
+func (c *countingEnricher) Enrich(ctx context.Context, q Query) (Result, error) {
+	c.calls++
+	return c.stubEnricher.Enrich(ctx, q)
+}
+
+func TestResultCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	cache := NewResultCache(2, time.Hour)
+	cache.Set("a", Result{Value: "1"})
+	cache.Set("b", Result{Value: "2"})
+
+	_, ok := cache.Get("a")
+	assert.True(t, ok)
+
+	cache.Set("c", Result{Value: "3"})
+
+	_, ok = cache.Get("b")
+	assert.False(t, ok)
+	_, ok = cache.Get("a")
+	assert.True(t, ok)
+	_, ok = cache.Get("c")
+	assert.True(t, ok)
+
+	stats := cache.Stats()
+	assert.Equal(t, uint64(3), stats.Hits)
+	assert.Equal(t, uint64(1), stats.Misses)
+	assert.Equal(t, uint64(1), stats.Evictions)
+	assert.Equal(t, 2, stats.Size)
+}
+
+func TestResultCache_Expires(t *testing.T) {
+	now := time.Now()
+	cache := NewResultCache(10, time.Minute)
+	cache.now = func() time.Time { return now }
+
+	cache.Set("a", Result{Value: "1"})
+	_, ok := cache.Get("a")
+	assert.True(t, ok)
+
+	now = now.Add(2 * time.Minute)
+	_, ok = cache.Get("a")
+	assert.False(t, ok)
+	assert.Equal(t, 0, cache.Stats().Size)
+}
+
+func TestCachedEnricher_NormalizesName(t *testing.T) {
+	inner := &countingEnricher{stubEnricher: stubEnricher{provider: "agify", attr: AttributeAge, value: "40"}}
+	e := WithCache(NewResultCache(10, time.Hour))(inner)
+
+	for _, name := range []string{"Ivan", " ivan", "IVAN "} {
+		res, err := e.Enrich(context.Background(), Query{Name: name})
+		assert.NoError(t, err)
+		assert.Equal(t, "40", res.Value)
+	}
+	assert.Equal(t, 1, inner.calls)
+}
+
+func TestCachedEnricher_BypassRefreshesEntry(t *testing.T) {
+	inner := &countingEnricher{stubEnricher: stubEnricher{provider: "agify", attr: AttributeAge, value: "40"}}
+	cache := NewResultCache(10, time.Hour)
+	e := WithCache(cache)(inner)
+
+	_, err := e.Enrich(context.Background(), Query{Name: "Ivan"})
+	assert.NoError(t, err)
+
+	inner.value = "41"
+	res, err := e.Enrich(withoutCache(context.Background()), Query{Name: "Ivan"})
+	assert.NoError(t, err)
+	assert.Equal(t, "41", res.Value)
+	assert.Equal(t, 2, inner.calls)
+
+	cached, ok := cache.Get(cacheKey("agify", Query{Name: "Ivan"}))
+	assert.True(t, ok)
+	assert.Equal(t, "41", cached.Value)
+}
+
+func TestResultCache_DeletePrefix(t *testing.T) {
+	cache := NewResultCache(10, time.Hour)
+	cache.Set("genderize:andrea", Result{Value: "female"})
+	cache.Set("genderize:andrea:IT", Result{Value: "male"})
+	cache.Set("genderize:andreas", Result{Value: "male"})
+
+	cache.DeletePrefix("genderize:andrea:")
+
+	_, ok := cache.Get("genderize:andrea:IT")
+	assert.False(t, ok)
+	_, ok = cache.Get("genderize:andrea")
+	assert.True(t, ok)
+	_, ok = cache.Get("genderize:andreas")
+	assert.True(t, ok)
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// CoalescingStatus reports how many lookups of a provider were answered by a
+// request already in flight instead of a call of their own.
+type CoalescingStatus struct {
+	Provider string `json:"provider"`
+	Calls    uint64 `json:"calls"`
+	Saved    uint64 `json:"saved"`
+}
+
+// Coalescer de-duplicates concurrent lookups of the same name: while one is
+// in flight, identical lookups wait for and share its result.
+type Coalescer struct {
+	group singleflight.Group
+
+	mu    sync.Mutex
+	stats map[string]*CoalescingStatus
+}
+
+func NewCoalescer() *Coalescer {
+	return &Coalescer{stats: make(map[string]*CoalescingStatus)}
+}
+
+// Status returns the counters of every provider seen so far, by name.
+func (c *Coalescer) Status() []CoalescingStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	statuses := make([]CoalescingStatus, 0, len(c.stats))
+	for _, s := range c.stats {
+		statuses = append(statuses, *s)
+	}
+	slices.SortFunc(statuses, func(a, b CoalescingStatus) int { return strings.Compare(a.Provider, b.Provider) })
+	return statuses
+}
+
+func (c *Coalescer) count(provider string, saved bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.stats[provider]
+	if !ok {
+		s = &CoalescingStatus{Provider: provider}
+		c.stats[provider] = s
+	}
+	s.Calls++
+	if saved {
+		s.Saved++
+	}
+}
+
+// WithCoalescing returns a middleware that shares in-flight lookups through c.
+// Batch lookups are passed through, they are de-duplicated by the batch itself.
+func WithCoalescing(c *Coalescer) EnricherMiddleware {
+	return func(e Enricher) Enricher {
+		return &coalescingEnricher{Enricher: e, coalescer: c}
+	}
+}
+
+type coalescingEnricher struct {
+	Enricher
+	coalescer *Coalescer
+}
+
+func (c *coalescingEnricher) Enrich(ctx context.Context, q Query) (Result, error) {
+	key := string(c.Attribute()) + ":" + cacheKey(c.Provider(), q)
+	if cacheBypassed(ctx) {
+		// A fresh lookup must not be answered from a cached one in flight.
+		key += ":fresh"
+	}
+
+	leader := false
+	ch := c.coalescer.group.DoChan(key, func() (interface{}, error) {
+		leader = true
+		return c.Enricher.Enrich(ctx, q)
+	})
+	select {
+	case r := <-ch:
+		c.coalescer.count(c.Provider(), !leader)
+		if r.Err != nil {
+			if !leader && isContextError(r.Err) && ctx.Err() == nil {
+				// The lookup we joined was cancelled by its own caller.
+				return c.Enricher.Enrich(ctx, q)
+			}
+			return Result{}, r.Err
+		}
+		return r.Val.(Result), nil
+	case <-ctx.Done():
+		return Result{}, ctx.Err()
+	}
+}
+
+func (c *coalescingEnricher) EnrichBatch(ctx context.Context, qs []Query) ([]Result, []error) {
+	return enrichBatch(ctx, c.Enricher, qs)
+}
+
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// gatedEnricher blocks every lookup until release is closed.
+type gatedEnricher struct {
+	stubEnricher
+	calls   atomic.Int32
+	release chan struct{}
+}
+
+func (g *gatedEnricher) Enrich(ctx context.Context, q Query) (Result, error) {
+	g.calls.Add(1)
+	select {
+	case <-g.release:
+	case <-ctx.Done():
+		return Result{}, ctx.Err()
+	}
+	return Result{Provider: g.provider, Attribute: g.attr, Value: q.Name}, nil
+}
+
+// waitForCalls waits until inner has been called n times.
+func waitForCalls(t *testing.T, inner *gatedEnricher, n int32) {
+	t.Helper()
+	assert.Eventually(t, func() bool { return inner.calls.Load() >= n }, time.Second, time.Millisecond)
+}
+
+func TestCoalescingEnricher_SharesInFlightLookup(t *testing.T) {
+	inner := &gatedEnricher{stubEnricher: stubEnricher{provider: "agify", attr: AttributeAge}, release: make(chan struct{})}
+	coalescer := NewCoalescer()
+	e := WithCoalescing(coalescer)(inner)
+
+	const n = 5
+	results := make([]Result, n)
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := e.Enrich(context.Background(), Query{Name: "ivan"})
+			assert.NoError(t, err)
+			results[i] = res
+		}()
+	}
+	waitForCalls(t, inner, 1)
+	// Give the followers time to join the lookup in flight.
+	time.Sleep(20 * time.Millisecond)
+	close(inner.release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), inner.calls.Load())
+	for _, res := range results {
+		assert.Equal(t, "ivan", res.Value)
+	}
+	assert.Equal(t, []CoalescingStatus{{Provider: "agify", Calls: n, Saved: n - 1}}, coalescer.Status())
+}
+
+func TestCoalescingEnricher_KeepsDifferentLookupsApart(t *testing.T) {
+	inner := &gatedEnricher{stubEnricher: stubEnricher{provider: "agify", attr: AttributeAge}, release: make(chan struct{})}
+	e := WithCoalescing(NewCoalescer())(inner)
+
+	ctxs := []context.Context{context.Background(), context.Background(), withoutCache(context.Background())}
+	queries := []Query{{Name: "ivan"}, {Name: "anna"}, {Name: "ivan"}}
+	var wg sync.WaitGroup
+	for i := range queries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := e.Enrich(ctxs[i], queries[i])
+			assert.NoError(t, err)
+			assert.Equal(t, queries[i].Name, res.Value)
+		}()
+	}
+	waitForCalls(t, inner, 3)
+	close(inner.release)
+	wg.Wait()
+
+	assert.Equal(t, int32(3), inner.calls.Load())
+}
+
+func TestCoalescingEnricher_FollowerOutlivesCancelledLeader(t *testing.T) {
+	inner := &gatedEnricher{stubEnricher: stubEnricher{provider: "agify", attr: AttributeAge}, release: make(chan struct{})}
+	e := WithCoalescing(NewCoalescer())(inner)
+
+	leaderCtx, cancel := context.WithCancel(context.Background())
+	leaderErr := make(chan error, 1)
+	go func() {
+		_, err := e.Enrich(leaderCtx, Query{Name: "ivan"})
+		leaderErr <- err
+	}()
+	waitForCalls(t, inner, 1)
+
+	followerDone := make(chan Result, 1)
+	go func() {
+		res, err := e.Enrich(context.Background(), Query{Name: "ivan"})
+		assert.NoError(t, err)
+		followerDone <- res
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	assert.ErrorIs(t, <-leaderErr, context.Canceled)
+
+	waitForCalls(t, inner, 2)
+	close(inner.release)
+	assert.Equal(t, "ivan", (<-followerDone).Value)
+}
@@ -0,0 +1,34 @@
+package service
+
+import "github.com/adal4ik/people-enrichment-service/internal/config"
+
+// Threshold is the minimum confidence required to accept an enriched value.
+// Zero fields are not checked.
+type Threshold struct {
+	MinProbability float64
+	MinSampleCount int
+}
+
+// ThresholdsFromConfig returns the configured threshold of every attribute.
+func ThresholdsFromConfig(cfg config.Config) map[Attribute]Threshold {
+	return map[Attribute]Threshold{
+		AttributeAge:         {MinSampleCount: cfg.AgeMinSampleCount},
+		AttributeGender:      {MinProbability: cfg.GenderMinProbability, MinSampleCount: cfg.GenderMinSampleCount},
+		AttributeNationality: {MinProbability: cfg.NationMinProbability, MinSampleCount: cfg.NationMinSampleCount},
+	}
+}
+
+// Accepts reports whether res is confident enough to be stored. Unknown
+// answers, and figures a provider does not report, are not held against it.
+func (t Threshold) Accepts(res Result) bool {
+	if res.Value == "" {
+		return true
+	}
+	if t.MinProbability > 0 && res.Probability != nil && *res.Probability < t.MinProbability {
+		return false
+	}
+	if t.MinSampleCount > 0 && res.SampleCount != nil && *res.SampleCount < t.MinSampleCount {
+		return false
+	}
+	return true
+}
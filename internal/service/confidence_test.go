@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/adal4ik/people-enrichment-service/internal/config"
+	"github.com/adal4ik/people-enrichment-service/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestThreshold_Accepts(t *testing.T) {
+	threshold := Threshold{MinProbability: 0.5, MinSampleCount: 10}
+	tests := []struct {
+		name string
+		res  Result
+		want bool
+	}{
+		{"confident", Result{Value: "RU", Probability: ptr(0.8), SampleCount: ptr(100)}, true},
+		{"low probability", Result{Value: "RU", Probability: ptr(0.2), SampleCount: ptr(100)}, false},
+		{"single sample", Result{Value: "RU", Probability: ptr(1.0), SampleCount: ptr(1)}, false},
+		{"figures not reported", Result{Value: "RU"}, true},
+		{"unknown", Result{Probability: ptr(0.0), SampleCount: ptr(0)}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, threshold.Accepts(tt.res))
+		})
+	}
+	assert.True(t, Threshold{}.Accepts(Result{Value: "RU", Probability: ptr(0.01), SampleCount: ptr(1)}))
+}
+
+func TestCreatePerson_StoresLowConfidenceValueAsUnknown(t *testing.T) {
+	repo := new(mockPersonRepo)
+	svc := newEnrichingService(repo, config.Config{NationMinProbability: 0.3, NationMinSampleCount: 5},
+		&stubEnricher{provider: "agify", attr: AttributeAge, value: "30", count: ptr(1)},
+		&stubEnricher{provider: "nationalize", attr: AttributeNationality, value: "UA", probability: ptr(0.9), count: ptr(1)},
+	)
+	repo.On("CreatePerson", mock.Anything, mock.MatchedBy(func(p models.Person) bool {
+		return p.Nationality == nil && p.Age != nil && p.EnrichmentStatus == models.EnrichmentCompleted
+	})).Return(nil)
+	repo.On("SaveEnrichments", mock.Anything, mock.Anything, mock.MatchedBy(func(e map[string]models.Enrichment) bool {
+		return e["nationality"].LowConfidence && *e["nationality"].Value == "UA" && !e["age"].LowConfidence
+	})).Return(nil)
+
+	created, err := svc.CreatePerson(context.Background(), models.Person{Name: "Taras"})
+	assert.NoError(t, err)
+	assert.Nil(t, created.Nationality)
+	assert.Equal(t, []string{"nationality"}, created.LowConfidenceAttributes())
+	repo.AssertExpectations(t)
+}
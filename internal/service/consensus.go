@@ -0,0 +1,235 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/adal4ik/people-enrichment-service/internal/config"
+)
+
+// Strategies for combining the answers of several providers of an attribute.
+const (
+	// StrategyFirstSuccess asks the providers in order and keeps the first
+	// answer that knows a value.
+	StrategyFirstSuccess = "first-success"
+	// StrategyHighestConfidence asks every provider and keeps the most
+	// probable answer, then the one backed by the most samples.
+	StrategyHighestConfidence = "highest-confidence"
+	// StrategyWeightedVote asks every provider and keeps the value with the
+	// highest sum of provider weight times probability.
+	StrategyWeightedVote = "weighted-vote"
+)
+
+var strategies = []string{StrategyFirstSuccess, StrategyHighestConfidence, StrategyWeightedVote}
+
+func strategyFor(cfg config.Config, attr Attribute) string {
+	var strategy string
+	switch attr {
+	case AttributeAge:
+		strategy = cfg.AgeStrategy
+	case AttributeGender:
+		strategy = cfg.GenderStrategy
+	case AttributeNationality:
+		strategy = cfg.NationStrategy
+	}
+	if strategy == "" {
+		return StrategyFirstSuccess
+	}
+	return strategy
+}
+
+// consensus reports whether attr is answered by combining every provider.
+func (r *Registry) consensus(attr Attribute) bool {
+	strategy, ok := r.strategies[attr]
+	return ok && strategy != StrategyFirstSuccess
+}
+
+// weight returns the vote weight of provider, 1 unless configured.
+func (r *Registry) weight(provider string) float64 {
+	if w, ok := r.weights[provider]; ok {
+		return w
+	}
+	return 1
+}
+
+// enrichConsensus asks every provider for attr at once and combines the
+// answers that know a value. Providers that fail are left out as long as
+// another one answers.
+func (r *Registry) enrichConsensus(ctx context.Context, attr Attribute, q Query) (Result, error) {
+	enrichers := r.enrichers[attr]
+	results := make([]Result, len(enrichers))
+	errs := make([]error, len(enrichers))
+
+	var wg sync.WaitGroup
+	for i, e := range enrichers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = e.Enrich(ctx, q)
+		}()
+	}
+	wg.Wait()
+	return r.combine(attr, enrichers, results, errs)
+}
+
+// enrichBatchConsensus is the batch counterpart of enrichConsensus.
+func (r *Registry) enrichBatchConsensus(ctx context.Context, attr Attribute, qs []Query) ([]Result, []error) {
+	enrichers := r.enrichers[attr]
+	batchResults := make([][]Result, len(enrichers))
+	batchErrs := make([][]error, len(enrichers))
+
+	var wg sync.WaitGroup
+	for i, e := range enrichers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			batchResults[i], batchErrs[i] = enrichBatch(ctx, e, qs)
+		}()
+	}
+	wg.Wait()
+
+	results := make([]Result, len(qs))
+	errs := make([]error, len(qs))
+	for j := range qs {
+		answers := make([]Result, len(enrichers))
+		answerErrs := make([]error, len(enrichers))
+		for i := range enrichers {
+			answers[i], answerErrs[i] = batchResults[i][j], batchErrs[i][j]
+		}
+		results[j], errs[j] = r.combine(attr, enrichers, answers, answerErrs)
+	}
+	return results, errs
+}
+
+// combine picks the answer for attr from the provider results, aligned with
+// enrichers, and records the strategy and the providers that knew a value.
+// Like first-success it falls back to the first unknown answer, and fails
+// only when no provider answered at all.
+func (r *Registry) combine(attr Attribute, enrichers []Enricher, results []Result, errs []error) (Result, error) {
+	var (
+		known   []Result
+		unknown *Result
+		failed  []error
+	)
+	for i, e := range enrichers {
+		switch {
+		case errs[i] != nil:
+			failed = append(failed, fmt.Errorf("%s: %w", e.Provider(), errs[i]))
+		case results[i].Value != "":
+			known = append(known, results[i])
+		case unknown == nil:
+			unknown = &results[i]
+		}
+	}
+	if len(known) == 0 {
+		if unknown != nil {
+			return *unknown, nil
+		}
+		return Result{}, errors.Join(failed...)
+	}
+
+	strategy := r.strategies[attr]
+	var res Result
+	switch strategy {
+	case StrategyWeightedVote:
+		res = r.vote(known)
+	default:
+		res = mostConfident(known)
+	}
+	res.Strategy = strategy
+	res.Sources = make([]string, len(known))
+	for i, k := range known {
+		res.Sources[i] = k.Provider
+	}
+	return res, nil
+}
+
+// mostConfident returns the answer with the highest probability, then the
+// highest sample count; answers without a figure rank below those with one
+// and ties go to the earlier provider.
+func mostConfident(known []Result) Result {
+	best := known[0]
+	for _, res := range known[1:] {
+		if c := compareFigure(res.Probability, best.Probability); c > 0 ||
+			c == 0 && compareFigure(res.SampleCount, best.SampleCount) > 0 {
+			best = res
+		}
+	}
+	return best
+}
+
+func compareFigure[T int | float64](a, b *T) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	case *a > *b:
+		return 1
+	case *a < *b:
+		return -1
+	}
+	return 0
+}
+
+// vote sums weight times probability, or just the weight when the provider
+// reports no probability, for every value. The winner is returned as answered
+// by its heaviest supporter, with the probability replaced by the winner's
+// share of all votes and the sample counts of its supporters added up.
+// Ties go to the value answered first.
+func (r *Registry) vote(known []Result) Result {
+	var (
+		values []string
+		total  float64
+	)
+	scores := make(map[string]float64)
+	for _, res := range known {
+		score := r.weight(res.Provider)
+		if res.Probability != nil {
+			score *= *res.Probability
+		}
+		if !slices.Contains(values, res.Value) {
+			values = append(values, res.Value)
+		}
+		scores[res.Value] += score
+		total += score
+	}
+	winner := values[0]
+	for _, value := range values[1:] {
+		if scores[value] > scores[winner] {
+			winner = value
+		}
+	}
+
+	var (
+		best    Result
+		weight  = -1.0
+		samples *int
+	)
+	for _, res := range known {
+		if res.Value != winner {
+			continue
+		}
+		if w := r.weight(res.Provider); w > weight {
+			best, weight = res, w
+		}
+		if res.SampleCount != nil {
+			n := *res.SampleCount
+			if samples != nil {
+				n += *samples
+			}
+			samples = &n
+		}
+	}
+	if total > 0 {
+		share := scores[winner] / total
+		best.Probability = &share
+	}
+	best.SampleCount = samples
+	return best
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/adal4ik/people-enrichment-service/internal/config"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func consensusRegistry(t *testing.T, strategy string, enrichers ...Enricher) *Registry {
+	t.Helper()
+	r := NewRegistry()
+	assert.NoError(t, r.SetStrategy(AttributeGender, strategy))
+	for _, e := range enrichers {
+		r.Register(e)
+	}
+	return r
+}
+
+func TestRegistry_HighestConfidence(t *testing.T) {
+	r := consensusRegistry(t, StrategyHighestConfidence,
+		&stubEnricher{provider: "rules", attr: AttributeGender, value: "male"},
+		&stubEnricher{provider: "local", attr: AttributeGender, value: "male", probability: ptr(0.7), count: ptr(5000)},
+		&stubEnricher{provider: "genderize", attr: AttributeGender, value: "female", probability: ptr(0.9), count: ptr(10)},
+		&stubEnricher{provider: "broken", attr: AttributeGender, err: errors.New("down")},
+	)
+
+	res, err := r.Enrich(context.Background(), AttributeGender, Query{Name: "sasha"})
+	assert.NoError(t, err)
+	assert.Equal(t, "genderize", res.Provider)
+	assert.Equal(t, "female", res.Value)
+	assert.Equal(t, StrategyHighestConfidence, res.Strategy)
+	assert.Equal(t, []string{"rules", "local", "genderize"}, res.Sources)
+}
+
+func TestRegistry_HighestConfidenceBreaksTiesBySampleCount(t *testing.T) {
+	r := consensusRegistry(t, StrategyHighestConfidence,
+		&stubEnricher{provider: "local", attr: AttributeGender, value: "male", probability: ptr(0.9), count: ptr(10)},
+		&stubEnricher{provider: "genderize", attr: AttributeGender, value: "female", probability: ptr(0.9), count: ptr(500)},
+	)
+
+	res, err := r.Enrich(context.Background(), AttributeGender, Query{Name: "sasha"})
+	assert.NoError(t, err)
+	assert.Equal(t, "genderize", res.Provider)
+}
+
+func TestRegistry_WeightedVote(t *testing.T) {
+	r := consensusRegistry(t, StrategyWeightedVote,
+		&stubEnricher{provider: "genderize", attr: AttributeGender, value: "female", probability: ptr(0.6), count: ptr(100)},
+		&stubEnricher{provider: "local", attr: AttributeGender, value: "male", probability: ptr(0.8), count: ptr(40)},
+		&stubEnricher{provider: "rules", attr: AttributeGender, value: "male"},
+	)
+	r.SetWeights(map[string]float64{"genderize": 3, "rules": 0.5})
+
+	// female: 3*0.6 = 1.8, male: 1*0.8 + 0.5 = 1.3
+	res, err := r.Enrich(context.Background(), AttributeGender, Query{Name: "sasha"})
+	assert.NoError(t, err)
+	assert.Equal(t, "female", res.Value)
+	assert.Equal(t, "genderize", res.Provider)
+	assert.InDelta(t, 1.8/3.1, *res.Probability, 1e-9)
+	assert.Equal(t, 100, *res.SampleCount)
+	assert.Equal(t, StrategyWeightedVote, res.Strategy)
+	assert.Equal(t, []string{"genderize", "local", "rules"}, res.Sources)
+
+	// With equal weights the two male votes win and their samples add up.
+	r.SetWeights(nil)
+	res, err = r.Enrich(context.Background(), AttributeGender, Query{Name: "sasha"})
+	assert.NoError(t, err)
+	assert.Equal(t, "male", res.Value)
+	assert.Equal(t, "local", res.Provider)
+	assert.Equal(t, 40, *res.SampleCount)
+	assert.InDelta(t, 1.8/2.4, *res.Probability, 1e-9)
+}
+
+func TestRegistry_ConsensusFallsBackToUnknownAndErrors(t *testing.T) {
+	r := consensusRegistry(t, StrategyWeightedVote,
+		&stubEnricher{provider: "broken", attr: AttributeGender, err: errors.New("down")},
+		&stubEnricher{provider: "local", attr: AttributeGender},
+	)
+	res, err := r.Enrich(context.Background(), AttributeGender, Query{Name: "zed"})
+	assert.NoError(t, err)
+	assert.Equal(t, "local", res.Provider)
+	assert.Empty(t, res.Value)
+	assert.Empty(t, res.Strategy)
+
+	r = consensusRegistry(t, StrategyWeightedVote,
+		&stubEnricher{provider: "first", attr: AttributeGender, err: errors.New("down")},
+		&stubEnricher{provider: "second", attr: AttributeGender, err: errors.New("timeout")},
+	)
+	_, err = r.Enrich(context.Background(), AttributeGender, Query{Name: "zed"})
+	assert.ErrorContains(t, err, "first: down")
+	assert.ErrorContains(t, err, "second: timeout")
+}
+
+func TestRegistry_EnrichBatchConsensus(t *testing.T) {
+	r := consensusRegistry(t, StrategyHighestConfidence,
+		&nameFailingEnricher{provider: "local", attr: AttributeGender, value: "male", unknown: "zed"},
+		&stubEnricher{provider: "genderize", attr: AttributeGender, value: "female", probability: ptr(0.9)},
+	)
+
+	results, errs := r.EnrichBatch(context.Background(), AttributeGender, []Query{{Name: "anna"}, {Name: "zed"}})
+	for _, err := range errs {
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, "female", results[0].Value)
+	assert.Equal(t, []string{"local", "genderize"}, results[0].Sources)
+	assert.Equal(t, "female", results[1].Value)
+	assert.Equal(t, []string{"genderize"}, results[1].Sources)
+}
+
+func TestNewRegistryFromConfig_Strategy(t *testing.T) {
+	r, err := NewRegistryFromConfig(config.Config{GenderStrategy: StrategyWeightedVote}, defaultClients, zap.NewNop())
+	assert.NoError(t, err)
+	assert.True(t, r.consensus(AttributeGender))
+	assert.False(t, r.consensus(AttributeAge))
+
+	_, err = NewRegistryFromConfig(config.Config{GenderStrategy: "majority"}, defaultClients, zap.NewNop())
+	assert.ErrorContains(t, err, `unknown gender strategy "majority"`)
+}
@@ -0,0 +1,236 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/adal4ik/people-enrichment-service/internal/models"
+	"go.uber.org/zap"
+)
+
+// Enrichment policies.
+const (
+	// PolicyStrict refuses to save a person unless every attribute was enriched.
+	PolicyStrict = "strict"
+	// PolicyBestEffort saves whatever succeeded and records the failed attributes.
+	PolicyBestEffort = "best-effort"
+)
+
+// errEnrichmentAborted is the cancel cause used when a sibling lookup has already failed.
+var errEnrichmentAborted = errors.New("enrichment aborted")
+
+// errEnrichmentInterrupted is recorded for persons whose background enrichment
+// did not finish before the service shut down.
+var errEnrichmentInterrupted = errors.New("enrichment interrupted by shutdown")
+
+func (p *PersonService) strict() bool {
+	return p.cfg.EnrichPolicy != PolicyBestEffort
+}
+
+// enrich looks up every configured attribute of q.
+func (p *PersonService) enrich(ctx context.Context, q Query) ([]Result, map[Attribute]error, error) {
+	return p.enrichAttributes(ctx, q, p.registry.Attributes())
+}
+
+// enrichAttributes looks up attrs concurrently under the shared enrichment
+// deadline. Under the strict policy the first failure cancels the remaining
+// lookups and all genuine failures are returned joined together. Under
+// best-effort the failures are returned per attribute instead, and only
+// cancellation by the caller is an error.
+//
+// Age and gender are localized with q.CountryID. When it is empty and
+// localization is enabled, nationality is looked up first and its best guess
+// is used as the country for the other attributes.
+func (p *PersonService) enrichAttributes(ctx context.Context, q Query, attrs []Attribute) ([]Result, map[Attribute]error, error) {
+	parent := ctx
+	if p.cfg.EnrichTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.cfg.EnrichTimeout)
+		defer cancel()
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	var results []Result
+	failures := make(map[Attribute]error)
+	lookup := func(q Query, attrs []Attribute) {
+		found := make([]Result, len(attrs))
+		errs := make([]error, len(attrs))
+
+		var wg sync.WaitGroup
+		for i, attr := range attrs {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				res, err := p.registry.Enrich(ctx, attr, q.forAttribute(attr))
+				if err != nil {
+					errs[i] = err
+					if p.strict() {
+						cancel(errEnrichmentAborted)
+					}
+					return
+				}
+				found[i] = res
+			}()
+		}
+		wg.Wait()
+
+		for i, err := range errs {
+			if err == nil {
+				results = append(results, found[i])
+				continue
+			}
+			if errors.Is(err, context.Canceled) && errors.Is(context.Cause(ctx), errEnrichmentAborted) {
+				continue
+			}
+			p.logger.Error("failed to get "+string(attrs[i]), zap.Error(err))
+			failures[attrs[i]] = err
+		}
+	}
+
+	if p.localizeFirst(q, attrs) {
+		lookup(q, []Attribute{AttributeNationality})
+		attrs = slices.DeleteFunc(slices.Clone(attrs), func(attr Attribute) bool {
+			return attr == AttributeNationality
+		})
+		q.CountryID = countryOf(results)
+	}
+	if len(failures) == 0 || !p.strict() {
+		lookup(q, attrs)
+	}
+
+	if len(failures) > 0 && (p.strict() || parent.Err() != nil) {
+		return nil, nil, joinFailures(failures)
+	}
+	return results, failures, nil
+}
+
+// localizeFirst reports whether nationality should be looked up before the
+// other attrs to provide their country.
+func (p *PersonService) localizeFirst(q Query, attrs []Attribute) bool {
+	if !p.cfg.EnrichLocalize || q.CountryID != "" || !slices.Contains(attrs, AttributeNationality) {
+		return false
+	}
+	return slices.ContainsFunc(attrs, localized)
+}
+
+// countryOf returns the most likely country among results, if any.
+func countryOf(results []Result) string {
+	for _, res := range results {
+		if res.Attribute == AttributeNationality {
+			return res.Value
+		}
+	}
+	return ""
+}
+
+// collect applies results to person and returns the enrichment details to
+// persist, including an entry for every failed attribute. Values below the
+// confidence threshold are left unknown and flagged. It sets the
+// enrichment status and, under the strict policy, fails on any failure.
+func (p *PersonService) collect(person *models.Person, results []Result, failures map[Attribute]error) (map[string]models.Enrichment, error) {
+	failed := make(map[Attribute]error, len(failures))
+	for attr, err := range failures {
+		failed[attr] = err
+	}
+
+	enrichments := make(map[string]models.Enrichment, len(results)+len(failed))
+	for _, res := range results {
+		if !p.thresholds[res.Attribute].Accepts(res) {
+			// Keep the weak answer for the record, but not on the person.
+			clearAttribute(person, res.Attribute)
+			e := res.Enrichment()
+			e.LowConfidence = true
+			enrichments[string(res.Attribute)] = e
+			p.logger.Info("rejected low-confidence value",
+				zap.String("attribute", string(res.Attribute)),
+				zap.String("provider", res.Provider),
+				zap.String("value", res.Value),
+				zap.Any("probability", res.Probability),
+				zap.Any("sample_count", res.SampleCount),
+			)
+			continue
+		}
+		if err := applyResult(person, res); err != nil {
+			failed[res.Attribute] = err
+			continue
+		}
+		enrichments[string(res.Attribute)] = res.Enrichment()
+		p.logger.Debug("enriched person data",
+			zap.String("attribute", string(res.Attribute)),
+			zap.String("provider", res.Provider),
+			zap.String("value", res.Value),
+			zap.Any("probability", res.Probability),
+			zap.Any("sample_count", res.SampleCount),
+		)
+	}
+
+	if len(failed) > 0 && p.strict() {
+		return nil, joinFailures(failed)
+	}
+
+	person.EnrichmentStatus = models.EnrichmentCompleted
+	if len(failed) > 0 {
+		person.EnrichmentStatus = models.EnrichmentPartial
+		now := time.Now()
+		for attr, err := range failed {
+			msg := err.Error()
+			enrichments[string(attr)] = models.Enrichment{Error: &msg, FetchedAt: now}
+			p.logger.Warn("saving person without "+string(attr), zap.String("name", person.Name), zap.Error(err))
+		}
+	}
+	if len(enrichments) > 0 {
+		person.Enrichment = enrichments
+	}
+	return enrichments, nil
+}
+
+// joinFailures formats per-attribute failures in attribute order.
+func joinFailures(failures map[Attribute]error) error {
+	var errs []error
+	for _, attr := range Attributes {
+		if err, ok := failures[attr]; ok {
+			errs = append(errs, fmt.Errorf("failed to enrich %s: %w", attr, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// queryFor builds the provider query for person. The first name is sent in
+// its normalized form; patronymic and surname stay as written for the rules.
+func (p *PersonService) queryFor(person models.Person) Query {
+	q := Query{Name: p.names.Normalize(person.Name), Surname: person.Surname}
+	if person.Patronymic != nil {
+		q.Patronymic = *person.Patronymic
+	}
+	if person.CountryHint != nil {
+		q.CountryID = *person.CountryHint
+	}
+	return q
+}
+
+// applyResult copies a provider answer into the matching person field.
+func applyResult(person *models.Person, res Result) error {
+	if res.Value == "" {
+		return nil
+	}
+	value := res.Value
+	switch res.Attribute {
+	case AttributeAge:
+		age, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid age %q from %s: %w", value, res.Provider, err)
+		}
+		person.Age = &age
+	case AttributeGender:
+		person.Gender = &value
+	case AttributeNationality:
+		person.Nationality = &value
+	}
+	return nil
+}
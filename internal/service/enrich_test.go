@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/adal4ik/people-enrichment-service/internal/config"
+	"github.com/adal4ik/people-enrichment-service/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+// countryEnricher answers by the country it is asked for and records the queries.
+type countryEnricher struct {
+	provider string
+	attr     Attribute
+	values   map[string]string
+
+	mu      sync.Mutex
+	queries []Query
+}
+
+func (c *countryEnricher) Provider() string     { return c.provider }
+func (c *countryEnricher) Attribute() Attribute { return c.attr }
+
+func (c *countryEnricher) Enrich(ctx context.Context, q Query) (Result, error) {
+	c.mu.Lock()
+	c.queries = append(c.queries, q)
+	c.mu.Unlock()
+	return Result{Attribute: c.attr, Provider: c.provider, Value: c.values[q.CountryID]}, nil
+}
+
+func TestRemoteProvider_SendsCountryID(t *testing.T) {
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.URL.RawQuery)
+		json.NewEncoder(w).Encode(map[string]interface{}{"name": "Andrea", "gender": "male", "probability": 0.98})
+	}))
+	defer srv.Close()
+
+	e, err := newGenderizeEnricher(AttributeGender, config.Config{APIGenderURL: srv.URL}, http.DefaultClient, zap.NewNop())
+	assert.NoError(t, err)
+
+	_, err = e.Enrich(context.Background(), Query{Name: "Andrea", CountryID: "IT"})
+	assert.NoError(t, err)
+	_, err = e.Enrich(context.Background(), Query{Name: "Andrea"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"country_id=IT&name=Andrea", "name=Andrea"}, got)
+}
+
+func TestRemoteProvider_EnrichBatchGroupsByCountry(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests = make(map[string][]string)
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		names := r.URL.Query()["name[]"]
+		country := r.URL.Query().Get("country_id")
+		mu.Lock()
+		requests[country] = append(requests[country], names...)
+		mu.Unlock()
+		items := make([]map[string]interface{}, len(names))
+		for i, name := range names {
+			items[i] = map[string]interface{}{"name": name, "gender": country, "probability": 0.9}
+		}
+		json.NewEncoder(w).Encode(items)
+	}))
+	defer srv.Close()
+
+	e, err := newGenderizeEnricher(AttributeGender, config.Config{APIGenderURL: srv.URL}, http.DefaultClient, zap.NewNop())
+	assert.NoError(t, err)
+
+	qs := []Query{{Name: "Andrea", CountryID: "IT"}, {Name: "Andrea", CountryID: "US"}, {Name: "Kim", CountryID: "IT"}}
+	results, errs := e.(BatchEnricher).EnrichBatch(context.Background(), qs)
+	for _, err := range errs {
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, "IT", results[0].Value)
+	assert.Equal(t, "US", results[1].Value)
+	assert.Equal(t, "IT", results[2].Value)
+	assert.Equal(t, map[string][]string{"IT": {"Andrea", "Kim"}, "US": {"Andrea"}}, requests)
+}
+
+func TestCreatePerson_LocalizesWithNationality(t *testing.T) {
+	repo := new(mockPersonRepo)
+	gender := &countryEnricher{provider: "genderize", attr: AttributeGender, values: map[string]string{"IT": "male", "": "female"}}
+	nation := &countryEnricher{provider: "nationalize", attr: AttributeNationality, values: map[string]string{"": "IT"}}
+	svc := newEnrichingService(repo, config.Config{EnrichLocalize: true}, gender, nation)
+
+	repo.On("CreatePerson", mock.Anything, mock.MatchedBy(func(p models.Person) bool {
+		return assert.Equal(t, ptr("male"), p.Gender) && assert.Equal(t, ptr("IT"), p.Nationality)
+	})).Return(nil)
+	repo.On("SaveEnrichments", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	_, err := svc.CreatePerson(context.Background(), models.Person{Name: "Andrea"})
+	assert.NoError(t, err)
+	assert.Equal(t, []Query{{Name: "andrea", CountryID: "IT"}}, gender.queries)
+	assert.Equal(t, []Query{{Name: "andrea"}}, nation.queries)
+}
+
+func TestCreatePerson_CountryHintWins(t *testing.T) {
+	repo := new(mockPersonRepo)
+	gender := &countryEnricher{provider: "genderize", attr: AttributeGender, values: map[string]string{"IT": "male", "US": "female"}}
+	nation := &countryEnricher{provider: "nationalize", attr: AttributeNationality, values: map[string]string{"": "US"}}
+	svc := newEnrichingService(repo, config.Config{EnrichLocalize: true}, gender, nation)
+
+	repo.On("CreatePerson", mock.Anything, mock.MatchedBy(func(p models.Person) bool {
+		return assert.Equal(t, ptr("male"), p.Gender) && assert.Equal(t, ptr("US"), p.Nationality)
+	})).Return(nil)
+	repo.On("SaveEnrichments", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	_, err := svc.CreatePerson(context.Background(), models.Person{Name: "Andrea", CountryHint: ptr("IT")})
+	assert.NoError(t, err)
+	// The hint never reaches the nationality lookup.
+	assert.Equal(t, []Query{{Name: "andrea"}}, nation.queries)
+}
+
+func TestCreatePerson_LocalizationDisabled(t *testing.T) {
+	repo := new(mockPersonRepo)
+	gender := &countryEnricher{provider: "genderize", attr: AttributeGender, values: map[string]string{"": "female"}}
+	nation := &countryEnricher{provider: "nationalize", attr: AttributeNationality, values: map[string]string{"": "IT"}}
+	svc := newEnrichingService(repo, config.Config{}, gender, nation)
+
+	repo.On("CreatePerson", mock.Anything, mock.Anything).Return(nil)
+	repo.On("SaveEnrichments", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	_, err := svc.CreatePerson(context.Background(), models.Person{Name: "Andrea"})
+	assert.NoError(t, err)
+	assert.Equal(t, []Query{{Name: "andrea"}}, gender.queries)
+}
+
+func TestCreatePerson_StrictNationalityFailureSkipsLocalizedLookups(t *testing.T) {
+	repo := new(mockPersonRepo)
+	gender := &countryEnricher{provider: "genderize", attr: AttributeGender}
+	svc := newEnrichingService(repo, config.Config{EnrichLocalize: true}, gender,
+		&stubEnricher{provider: "nationalize", attr: AttributeNationality, err: errors.New("nation error")},
+	)
+
+	_, err := svc.CreatePerson(context.Background(), models.Person{Name: "Andrea"})
+	assert.ErrorContains(t, err, "nation error")
+	assert.Empty(t, gender.queries)
+}
+
+func TestEnrichBatch_LocalizesPerQuery(t *testing.T) {
+	gender := &countryEnricher{provider: "genderize", attr: AttributeGender, values: map[string]string{"IT": "male", "US": "female"}}
+	nation := &countryEnricher{provider: "nationalize", attr: AttributeNationality, values: map[string]string{"": "US"}}
+	svc := newEnrichingService(new(mockPersonRepo), config.Config{EnrichLocalize: true}, gender, nation)
+
+	out := svc.EnrichBatch(context.Background(), []Query{{Name: "Andrea", CountryID: "IT"}, {Name: "Andrea"}})
+	assert.Equal(t, "male", out[0].Results[1].Value)
+	assert.Equal(t, "female", out[1].Results[1].Value)
+	assert.Equal(t, "", out[1].Query.CountryID)
+}
+
+func TestCacheKey_IncludesCountry(t *testing.T) {
+	assert.Equal(t, "genderize:andrea", cacheKey("genderize", Query{Name: " Andrea"}))
+	assert.Equal(t, "genderize:andrea:IT", cacheKey("genderize", Query{Name: "Andrea", CountryID: "IT"}))
+}
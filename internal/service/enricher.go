@@ -0,0 +1,376 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/adal4ik/people-enrichment-service/internal/config"
+	"github.com/adal4ik/people-enrichment-service/internal/models"
+	"github.com/adal4ik/people-enrichment-service/utils"
+	"go.uber.org/zap"
+)
+
+// Attribute is a person field that can be filled in by an enrichment provider.
+type Attribute string
+
+const (
+	AttributeAge         Attribute = "age"
+	AttributeGender      Attribute = "gender"
+	AttributeNationality Attribute = "nationality"
+)
+
+// Attributes lists every enrichable attribute in the order they are applied.
+var Attributes = []Attribute{AttributeAge, AttributeGender, AttributeNationality}
+
+// ParseAttributes parses a comma-separated attribute list such as "age,gender".
+func ParseAttributes(s string) ([]Attribute, error) {
+	var attrs []Attribute
+	for _, field := range strings.Split(s, ",") {
+		attr := Attribute(strings.ToLower(strings.TrimSpace(field)))
+		if attr == "" {
+			continue
+		}
+		if !slices.Contains(Attributes, attr) {
+			return nil, fmt.Errorf("%q: %w", field, utils.ErrUnknownAttribute)
+		}
+		if !slices.Contains(attrs, attr) {
+			attrs = append(attrs, attr)
+		}
+	}
+	return attrs, nil
+}
+
+// Query is the input handed to an enricher.
+type Query struct {
+	Name       string
+	Surname    string
+	Patronymic string
+	// CountryID is an ISO 3166-1 alpha-2 code that localizes age and gender
+	// predictions; empty when unknown.
+	CountryID string
+}
+
+// localized reports whether answers for attr depend on the country.
+func localized(attr Attribute) bool {
+	return attr == AttributeAge || attr == AttributeGender
+}
+
+// forAttribute returns q as it should be asked for attr: the country is
+// dropped for attributes it does not affect so that they share cache entries.
+func (q Query) forAttribute(attr Attribute) Query {
+	if !localized(attr) {
+		q.CountryID = ""
+	}
+	return q
+}
+
+// Result is a single attribute value produced by a provider.
+// An empty Value means the provider does not know the answer.
+// Probability and SampleCount are nil when the provider does not report them,
+// and Evidence holds the raw provider payload the value was taken from.
+// Strategy and Sources are set when the answers of several providers were
+// combined into this one.
+type Result struct {
+	Attribute   Attribute
+	Provider    string
+	Value       string
+	Probability *float64
+	SampleCount *int
+	Evidence    json.RawMessage
+	Strategy    string
+	Sources     []string
+	FetchedAt   time.Time
+}
+
+// Enrichment converts the result into its persisted form.
+func (r Result) Enrichment() models.Enrichment {
+	e := models.Enrichment{
+		Provider:    r.Provider,
+		Probability: r.Probability,
+		SampleCount: r.SampleCount,
+		Evidence:    r.Evidence,
+		Strategy:    r.Strategy,
+		Sources:     r.Sources,
+		FetchedAt:   r.FetchedAt,
+	}
+	if r.Value != "" {
+		value := r.Value
+		e.Value = &value
+	}
+	return e
+}
+
+func resultFromEnrichment(attr Attribute, e models.Enrichment) Result {
+	res := Result{
+		Attribute:   attr,
+		Provider:    e.Provider,
+		Probability: e.Probability,
+		SampleCount: e.SampleCount,
+		Evidence:    e.Evidence,
+		Strategy:    e.Strategy,
+		Sources:     e.Sources,
+		FetchedAt:   e.FetchedAt,
+	}
+	if e.Value != nil {
+		res.Value = *e.Value
+	}
+	return res
+}
+
+// Enricher looks up one attribute for a person.
+type Enricher interface {
+	Provider() string
+	Attribute() Attribute
+	Enrich(ctx context.Context, q Query) (Result, error)
+}
+
+// BatchEnricher is implemented by enrichers that can look up several queries in
+// one call. Results and errors are aligned with the input queries.
+type BatchEnricher interface {
+	Enricher
+	EnrichBatch(ctx context.Context, qs []Query) ([]Result, []error)
+}
+
+// enrichBatch uses the batch path of e when it has one and falls back to
+// one lookup per query otherwise.
+func enrichBatch(ctx context.Context, e Enricher, qs []Query) ([]Result, []error) {
+	if b, ok := e.(BatchEnricher); ok {
+		return b.EnrichBatch(ctx, qs)
+	}
+	results := make([]Result, len(qs))
+	errs := make([]error, len(qs))
+	for i, q := range qs {
+		results[i], errs[i] = e.Enrich(ctx, q)
+	}
+	return results, errs
+}
+
+// InProcessEnricher is implemented by providers that answer without network
+// calls. The middlewares meant for remote providers, such as the breaker and
+// the name-keyed caches, are not applied to them.
+type InProcessEnricher interface {
+	Enricher
+	InProcess()
+}
+
+// EnricherMiddleware wraps an enricher with additional behaviour such as caching.
+type EnricherMiddleware func(Enricher) Enricher
+
+// ProviderFactory builds an enricher for the given attribute.
+type ProviderFactory func(attr Attribute, cfg config.Config, client HTTPDoer, logger *zap.Logger) (Enricher, error)
+
+var providerFactories = map[string]ProviderFactory{
+	"agify":       newAgifyEnricher,
+	"genderize":   newGenderizeEnricher,
+	"nationalize": newNationalizeEnricher,
+	"local":       newLocalEnricher,
+	"rules":       newRulesEnricher,
+}
+
+// Registry keeps the configured enrichers for each attribute in priority order,
+// and how their answers are combined.
+type Registry struct {
+	enrichers  map[Attribute][]Enricher
+	strategies map[Attribute]string
+	weights    map[string]float64
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		enrichers:  make(map[Attribute][]Enricher),
+		strategies: make(map[Attribute]string),
+	}
+}
+
+// SetStrategy sets how the answers of the providers for attr are combined.
+func (r *Registry) SetStrategy(attr Attribute, strategy string) error {
+	if !slices.Contains(strategies, strategy) {
+		return fmt.Errorf("unknown %s strategy %q", attr, strategy)
+	}
+	r.strategies[attr] = strategy
+	return nil
+}
+
+// SetWeights sets the vote weights of providers; unlisted providers weigh 1.
+func (r *Registry) SetWeights(weights map[string]float64) {
+	r.weights = weights
+}
+
+// NewRegistryFromConfig registers the providers listed in cfg for every attribute.
+// Middlewares are applied in order, so the first one wraps the provider directly.
+func NewRegistryFromConfig(cfg config.Config, clients ClientFactory, logger *zap.Logger, middlewares ...EnricherMiddleware) (*Registry, error) {
+	r := NewRegistry()
+	r.SetWeights(cfg.ProviderWeights)
+	for _, attr := range Attributes {
+		if err := r.SetStrategy(attr, strategyFor(cfg, attr)); err != nil {
+			return nil, err
+		}
+		for _, name := range providersFor(cfg, attr) {
+			factory, ok := providerFactories[name]
+			if !ok {
+				return nil, fmt.Errorf("unknown %s provider %q", attr, name)
+			}
+			e, err := factory(attr, cfg, clients(name), logger)
+			if err != nil {
+				return nil, fmt.Errorf("failed to build %s provider %q: %w", attr, name, err)
+			}
+			if _, ok := e.(InProcessEnricher); !ok {
+				for _, mw := range middlewares {
+					e = mw(e)
+				}
+			}
+			r.Register(e)
+			logger.Info("registered enrichment provider",
+				zap.String("attribute", string(attr)),
+				zap.String("provider", name),
+			)
+		}
+	}
+	return r, nil
+}
+
+func providersFor(cfg config.Config, attr Attribute) []string {
+	switch attr {
+	case AttributeAge:
+		return cfg.AgeProviders
+	case AttributeGender:
+		return cfg.GenderProviders
+	case AttributeNationality:
+		return cfg.NationProviders
+	}
+	return nil
+}
+
+func (r *Registry) Register(e Enricher) {
+	r.enrichers[e.Attribute()] = append(r.enrichers[e.Attribute()], e)
+}
+
+func (r *Registry) Enrichers(attr Attribute) []Enricher {
+	return r.enrichers[attr]
+}
+
+// Providers returns the distinct provider names in registration order.
+func (r *Registry) Providers() []string {
+	var providers []string
+	seen := make(map[string]bool)
+	for _, attr := range Attributes {
+		for _, e := range r.enrichers[attr] {
+			if !seen[e.Provider()] {
+				seen[e.Provider()] = true
+				providers = append(providers, e.Provider())
+			}
+		}
+	}
+	return providers
+}
+
+// Attributes returns the attributes that have at least one provider.
+func (r *Registry) Attributes() []Attribute {
+	var attrs []Attribute
+	for _, attr := range Attributes {
+		if len(r.enrichers[attr]) > 0 {
+			attrs = append(attrs, attr)
+		}
+	}
+	return attrs
+}
+
+// Enrich asks the providers for attr in order and returns the first answer
+// that knows a value. When every provider answers but none knows the name, the
+// first unknown answer is returned. Under a consensus strategy all providers
+// are asked at once and their answers combined instead.
+func (r *Registry) Enrich(ctx context.Context, attr Attribute, q Query) (Result, error) {
+	enrichers := r.enrichers[attr]
+	if len(enrichers) == 0 {
+		return Result{}, fmt.Errorf("%s: %w", attr, utils.ErrNoProvider)
+	}
+	if r.consensus(attr) {
+		return r.enrichConsensus(ctx, attr, q)
+	}
+	var (
+		errs    []error
+		unknown *Result
+	)
+	for _, e := range enrichers {
+		res, err := e.Enrich(ctx, q)
+		if err == nil {
+			if res.Value != "" {
+				return res, nil
+			}
+			if unknown == nil {
+				unknown = &res
+			}
+			continue
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", e.Provider(), err))
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	if unknown != nil {
+		return *unknown, nil
+	}
+	return Result{}, errors.Join(errs...)
+}
+
+// EnrichBatch is the batch counterpart of Enrich: queries a provider could not
+// answer, or did not know, are retried with the next provider for attr.
+func (r *Registry) EnrichBatch(ctx context.Context, attr Attribute, qs []Query) ([]Result, []error) {
+	results := make([]Result, len(qs))
+	errs := make([]error, len(qs))
+	enrichers := r.enrichers[attr]
+	if len(enrichers) == 0 {
+		for i := range errs {
+			errs[i] = fmt.Errorf("%s: %w", attr, utils.ErrNoProvider)
+		}
+		return results, errs
+	}
+	if r.consensus(attr) {
+		return r.enrichBatchConsensus(ctx, attr, qs)
+	}
+
+	pending := make([]int, len(qs))
+	for i := range qs {
+		pending[i] = i
+	}
+	// answered marks queries that already hold an unknown answer, which later
+	// provider errors must not replace.
+	answered := make([]bool, len(qs))
+	for _, e := range enrichers {
+		batch := make([]Query, len(pending))
+		for j, i := range pending {
+			batch[j] = qs[i]
+		}
+		batchResults, batchErrs := enrichBatch(ctx, e, batch)
+
+		var retry []int
+		for j, i := range pending {
+			if batchErrs[j] != nil {
+				if !answered[i] {
+					errs[i] = errors.Join(errs[i], fmt.Errorf("%s: %w", e.Provider(), batchErrs[j]))
+				}
+				retry = append(retry, i)
+				continue
+			}
+			if batchResults[j].Value == "" {
+				// Keep the first unknown answer unless a later provider knows better.
+				if !answered[i] {
+					results[i], errs[i], answered[i] = batchResults[j], nil, true
+				}
+				retry = append(retry, i)
+				continue
+			}
+			results[i], errs[i] = batchResults[j], nil
+		}
+		pending = retry
+		if len(pending) == 0 || ctx.Err() != nil {
+			break
+		}
+	}
+	return results, errs
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/adal4ik/people-enrichment-service/internal/config"
+	"github.com/adal4ik/people-enrichment-service/utils"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+type stubEnricher struct {
+	provider    string
+	attr        Attribute
+	value       string
+	probability *float64
+	count       *int
+	err         error
+}
+
+func (s *stubEnricher) Provider() string     { return s.provider }
+func (s *stubEnricher) Attribute() Attribute { return s.attr }
+
+func (s *stubEnricher) Enrich(ctx context.Context, q Query) (Result, error) {
+	if s.err != nil {
+		return Result{}, s.err
+	}
+	return Result{
+		Attribute:   s.attr,
+		Provider:    s.provider,
+		Value:       s.value,
+		Probability: s.probability,
+		SampleCount: s.count,
+		FetchedAt:   time.Now(),
+	}, nil
+}
+
+func defaultClients(provider string) HTTPDoer {
+	return http.DefaultClient
+}
+
+func TestRegistry_FallsBackToNextProvider(t *testing.T) {
+	r := NewRegistry()
+	r.Register(&stubEnricher{provider: "first", attr: AttributeGender, err: errors.New("down")})
+	r.Register(&stubEnricher{provider: "second", attr: AttributeGender, value: "female"})
+
+	res, err := r.Enrich(context.Background(), AttributeGender, Query{Name: "Anna"})
+	assert.NoError(t, err)
+	assert.Equal(t, "second", res.Provider)
+	assert.Equal(t, "female", res.Value)
+}
+
+func TestRegistry_FallsBackOnUnknownAnswer(t *testing.T) {
+	r := NewRegistry()
+	r.Register(&stubEnricher{provider: "local", attr: AttributeGender})
+	r.Register(&stubEnricher{provider: "genderize", attr: AttributeGender, value: "female"})
+
+	res, err := r.Enrich(context.Background(), AttributeGender, Query{Name: "Anna"})
+	assert.NoError(t, err)
+	assert.Equal(t, "genderize", res.Provider)
+
+	r = NewRegistry()
+	r.Register(&stubEnricher{provider: "local", attr: AttributeGender})
+	r.Register(&stubEnricher{provider: "genderize", attr: AttributeGender, err: errors.New("down")})
+
+	res, err = r.Enrich(context.Background(), AttributeGender, Query{Name: "Anna"})
+	assert.NoError(t, err)
+	assert.Equal(t, "local", res.Provider)
+	assert.Empty(t, res.Value)
+}
+
+func TestRegistry_EnrichBatchFallsBackOnUnknownAnswer(t *testing.T) {
+	r := NewRegistry()
+	r.Register(&nameFailingEnricher{provider: "local", attr: AttributeGender, value: "female", unknown: "Zed"})
+	r.Register(&stubEnricher{provider: "genderize", attr: AttributeGender, err: errors.New("down")})
+
+	results, errs := r.EnrichBatch(context.Background(), AttributeGender, []Query{{Name: "Anna"}, {Name: "Zed"}})
+	assert.NoError(t, errs[0])
+	assert.NoError(t, errs[1])
+	assert.Equal(t, "female", results[0].Value)
+	assert.Equal(t, "local", results[1].Provider)
+	assert.Empty(t, results[1].Value)
+}
+
+func TestRegistry_NoProvider(t *testing.T) {
+	r := NewRegistry()
+	_, err := r.Enrich(context.Background(), AttributeAge, Query{Name: "Anna"})
+	assert.ErrorIs(t, err, utils.ErrNoProvider)
+	assert.Empty(t, r.Attributes())
+}
+
+func TestNewRegistryFromConfig(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Anna", r.URL.Query().Get("name"))
+		w.Write([]byte(`{"name":"Anna","age":42,"count":100}`))
+	}))
+	defer srv.Close()
+
+	cfg := config.Config{APIAgeURL: srv.URL, AgeProviders: []string{"agify"}}
+	r, err := NewRegistryFromConfig(cfg, defaultClients, zap.NewNop())
+	assert.NoError(t, err)
+	assert.Equal(t, []Attribute{AttributeAge}, r.Attributes())
+
+	res, err := r.Enrich(context.Background(), AttributeAge, Query{Name: "Anna"})
+	assert.NoError(t, err)
+	assert.Equal(t, "42", res.Value)
+}
+
+func TestNewRegistryFromConfig_UnknownProvider(t *testing.T) {
+	_, err := NewRegistryFromConfig(config.Config{GenderProviders: []string{"nope"}}, defaultClients, zap.NewNop())
+	assert.Error(t, err)
+
+	_, err = NewRegistryFromConfig(config.Config{GenderProviders: []string{"agify"}}, defaultClients, zap.NewNop())
+	assert.Error(t, err)
+}
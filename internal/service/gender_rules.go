@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/adal4ik/people-enrichment-service/internal/config"
+	"go.uber.org/zap"
+)
+
+// genderRule maps a name ending to a gender.
+type genderRule struct {
+	suffix string
+	gender string
+}
+
+// Patronymic endings, longest first so that "-ична" wins over "-ич". The
+// Turkic "оглы"/"кызы" forms are written as separate words but end the
+// patronymic all the same.
+var patronymicRules = []genderRule{
+	{"инична", "female"}, {"ична", "female"}, {"овна", "female"}, {"евна", "female"}, {"кызы", "female"},
+	{"ович", "male"}, {"евич", "male"}, {"ич", "male"}, {"оглы", "male"},
+	{"inichna", "female"}, {"ichna", "female"}, {"ovna", "female"}, {"evna", "female"}, {"kyzy", "female"},
+	{"ovich", "male"}, {"evich", "male"}, {"ich", "male"}, {"ogly", "male"},
+}
+
+// Surname endings of the Russian declension, longest first so that "-tskaya"
+// wins over "-skaya". Latin "-in"/"-ina" are left out, they are too common
+// outside Slavic names.
+var surnameRules = []genderRule{
+	{"ская", "female"}, {"цкая", "female"}, {"ова", "female"}, {"ева", "female"}, {"ёва", "female"}, {"ина", "female"}, {"ына", "female"},
+	{"ский", "male"}, {"цкий", "male"}, {"ской", "male"}, {"ов", "male"}, {"ев", "male"}, {"ёв", "male"}, {"ин", "male"}, {"ын", "male"},
+	{"tskaya", "female"}, {"skaya", "female"}, {"ova", "female"}, {"eva", "female"},
+	{"skiy", "male"}, {"skii", "male"}, {"sky", "male"}, {"ov", "male"}, {"ev", "male"},
+}
+
+// Confidence reported for each kind of rule: a patronymic is unambiguous, a
+// surname ending much less so.
+const (
+	patronymicRuleProbability = 0.99
+	surnameRuleProbability    = 0.9
+)
+
+func matchRule(rules []genderRule, word string) (genderRule, bool) {
+	word = strings.ToLower(strings.TrimSpace(word))
+	if word == "" {
+		return genderRule{}, false
+	}
+	for _, rule := range rules {
+		// The word must be longer than the ending itself, "Ов" is not a surname.
+		if len([]rune(word)) > len([]rune(rule.suffix)) && strings.HasSuffix(word, rule.suffix) {
+			return rule, true
+		}
+	}
+	return genderRule{}, false
+}
+
+// inferGender applies the patronymic rules and then the surname rules to q.
+// The Value of the result is empty when no rule matches.
+func inferGender(q Query) Result {
+	res := Result{Attribute: AttributeGender, Provider: "rules", FetchedAt: time.Now()}
+
+	var (
+		evidence    = map[string]string{}
+		probability float64
+	)
+	if rule, ok := matchRule(patronymicRules, q.Patronymic); ok {
+		res.Value, probability = rule.gender, patronymicRuleProbability
+		evidence["rule"], evidence["input"], evidence["suffix"] = "patronymic", q.Patronymic, rule.suffix
+	} else if rule, ok := matchRule(surnameRules, q.Surname); ok {
+		res.Value, probability = rule.gender, surnameRuleProbability
+		evidence["rule"], evidence["input"], evidence["suffix"] = "surname", q.Surname, rule.suffix
+	} else {
+		return res
+	}
+
+	res.Probability = &probability
+	res.Evidence, _ = json.Marshal(evidence)
+	return res
+}
+
+// rulesProvider infers gender from the patronymic and surname of a query.
+type rulesProvider struct{}
+
+func newRulesEnricher(attr Attribute, cfg config.Config, client HTTPDoer, logger *zap.Logger) (Enricher, error) {
+	if attr != AttributeGender {
+		return nil, fmt.Errorf("rules do not provide %s", attr)
+	}
+	return rulesProvider{}, nil
+}
+
+func (rulesProvider) Provider() string     { return "rules" }
+func (rulesProvider) Attribute() Attribute { return AttributeGender }
+func (rulesProvider) InProcess()           {}
+
+func (rulesProvider) Enrich(ctx context.Context, q Query) (Result, error) {
+	return inferGender(q), nil
+}
+
+// genderTieBreaker replaces uncertain gender answers of a remote provider with
+// the rule-based inference when a rule matches.
+type genderTieBreaker struct {
+	Enricher
+	threshold float64
+	logger    *zap.Logger
+}
+
+// WithGenderTieBreak returns a middleware that lets the patronymic and surname
+// rules decide when a gender provider does not know the name or answers with a
+// probability below threshold. Other attributes are left as they are.
+func WithGenderTieBreak(threshold float64, logger *zap.Logger) EnricherMiddleware {
+	return func(e Enricher) Enricher {
+		if e.Attribute() != AttributeGender {
+			return e
+		}
+		return &genderTieBreaker{Enricher: e, threshold: threshold, logger: logger}
+	}
+}
+
+func (g *genderTieBreaker) Enrich(ctx context.Context, q Query) (Result, error) {
+	res, err := g.Enricher.Enrich(ctx, q)
+	if err != nil {
+		return Result{}, err
+	}
+	return g.decide(q, res), nil
+}
+
+func (g *genderTieBreaker) EnrichBatch(ctx context.Context, qs []Query) ([]Result, []error) {
+	results, errs := enrichBatch(ctx, g.Enricher, qs)
+	for i := range qs {
+		if errs[i] == nil {
+			results[i] = g.decide(qs[i], results[i])
+		}
+	}
+	return results, errs
+}
+
+func (g *genderTieBreaker) decide(q Query, res Result) Result {
+	if res.Value != "" && (res.Probability == nil || *res.Probability >= g.threshold) {
+		return res
+	}
+	inferred := inferGender(q)
+	if inferred.Value == "" || inferred.Value == res.Value {
+		return res
+	}
+
+	g.logger.Debug("gender decided by rules",
+		zap.String("provider", res.Provider),
+		zap.String("remote", res.Value),
+		zap.Any("remote_probability", res.Probability),
+		zap.String("inferred", inferred.Value),
+	)
+	evidence := map[string]json.RawMessage{"rules": inferred.Evidence}
+	if len(res.Evidence) > 0 {
+		evidence[res.Provider] = res.Evidence
+	}
+	inferred.Evidence, _ = json.Marshal(evidence)
+	return inferred
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/adal4ik/people-enrichment-service/internal/config"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestInferGender(t *testing.T) {
+	tests := []struct {
+		name  string
+		query Query
+		want  string
+		rule  string
+	}{
+		{"cyrillic male patronymic", Query{Patronymic: "Васильевич"}, "male", "patronymic"},
+		{"cyrillic female patronymic", Query{Patronymic: "Ильинична"}, "female", "patronymic"},
+		{"short male patronymic", Query{Patronymic: "Ильич"}, "male", "patronymic"},
+		{"latin female patronymic", Query{Patronymic: "Petrovna"}, "female", "patronymic"},
+		{"latin male patronymic", Query{Patronymic: "Vasilevich"}, "male", "patronymic"},
+		{"turkic patronymic", Query{Patronymic: "Алиев оглы"}, "male", "patronymic"},
+		{"patronymic beats surname", Query{Patronymic: "Ивановна", Surname: "Петров"}, "female", "patronymic"},
+		{"cyrillic female surname", Query{Surname: "Иванова"}, "female", "surname"},
+		{"cyrillic male surname", Query{Surname: "Достоевский"}, "male", "surname"},
+		{"latin female surname", Query{Surname: "Kowalskaya"}, "female", "surname"},
+		{"latin female surname in -tskaya", Query{Surname: "Trotskaya"}, "female", "surname"},
+		{"latin male surname", Query{Surname: "Ushakov"}, "male", "surname"},
+		{"no rule", Query{Surname: "Smith"}, "", ""},
+		{"ending alone is not a name", Query{Surname: "Ов"}, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := inferGender(tt.query)
+			assert.Equal(t, tt.want, res.Value)
+			if tt.rule == "" {
+				assert.Nil(t, res.Probability)
+				return
+			}
+			var evidence map[string]string
+			assert.NoError(t, json.Unmarshal(res.Evidence, &evidence))
+			assert.Equal(t, tt.rule, evidence["rule"])
+		})
+	}
+}
+
+func TestGenderRules_NoEndingIsShadowed(t *testing.T) {
+	for _, rules := range [][]genderRule{patronymicRules, surnameRules} {
+		for i, earlier := range rules {
+			for _, later := range rules[i+1:] {
+				assert.False(t, strings.HasSuffix(later.suffix, earlier.suffix),
+					"%q can never match after %q", later.suffix, earlier.suffix)
+			}
+		}
+	}
+
+	rule, ok := matchRule(surnameRules, "Trotskaya")
+	assert.True(t, ok)
+	assert.Equal(t, "tskaya", rule.suffix)
+}
+
+func TestRulesProvider_OnlyGender(t *testing.T) {
+	_, err := newRulesEnricher(AttributeAge, config.Config{}, nil, zap.NewNop())
+	assert.Error(t, err)
+
+	e, err := newRulesEnricher(AttributeGender, config.Config{}, nil, zap.NewNop())
+	assert.NoError(t, err)
+	res, err := e.Enrich(context.Background(), Query{Name: "Sasha", Patronymic: "Sergeevna"})
+	assert.NoError(t, err)
+	assert.Equal(t, "female", res.Value)
+	assert.Equal(t, "rules", res.Provider)
+}
+
+func TestGenderTieBreak(t *testing.T) {
+	q := Query{Name: "Sasha", Surname: "Pushkina", Patronymic: "Sergeevna"}
+	remote := func(value string, probability float64) Enricher {
+		return &stubEnricher{provider: "genderize", attr: AttributeGender, value: value, probability: &probability}
+	}
+	mw := WithGenderTieBreak(0.8, zap.NewNop())
+
+	res, err := mw(remote("male", 0.55)).Enrich(context.Background(), q)
+	assert.NoError(t, err)
+	assert.Equal(t, "female", res.Value)
+	assert.Equal(t, "rules", res.Provider)
+	var evidence map[string]json.RawMessage
+	assert.NoError(t, json.Unmarshal(res.Evidence, &evidence))
+	assert.Contains(t, evidence, "rules")
+
+	res, err = mw(remote("male", 0.95)).Enrich(context.Background(), q)
+	assert.NoError(t, err)
+	assert.Equal(t, "genderize", res.Provider)
+
+	res, err = mw(remote("male", 0.55)).Enrich(context.Background(), Query{Name: "Sasha", Surname: "Smith"})
+	assert.NoError(t, err)
+	assert.Equal(t, "genderize", res.Provider)
+
+	age := &stubEnricher{provider: "agify", attr: AttributeAge, value: "30"}
+	assert.Same(t, Enricher(age), mw(age))
+}
+
+func TestGenderTieBreak_Batch(t *testing.T) {
+	mw := WithGenderTieBreak(0.8, zap.NewNop())
+	e := mw(&stubEnricher{provider: "genderize", attr: AttributeGender}).(BatchEnricher)
+
+	results, errs := e.EnrichBatch(context.Background(), []Query{{Name: "Sasha", Patronymic: "Sergeevich"}, {Name: "Kim"}})
+	assert.NoError(t, errs[0])
+	assert.Equal(t, "male", results[0].Value)
+	assert.Empty(t, results[1].Value)
+}
+
+func TestNewRegistryFromConfig_SkipsMiddlewaresForInProcessProviders(t *testing.T) {
+	wrapped := 0
+	count := func(e Enricher) Enricher {
+		wrapped++
+		return e
+	}
+	r, err := NewRegistryFromConfig(config.Config{GenderProviders: []string{"rules", "genderize"}}, defaultClients, zap.NewNop(), count)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, wrapped)
+	assert.Equal(t, []string{"rules", "genderize"}, r.Providers())
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/adal4ik/people-enrichment-service/internal/config"
+)
+
+// NewProviderHTTPClient builds the client used for all outbound provider
+// calls. Unlike http.DefaultClient it bounds every attempt with a timeout,
+// limits idle connections and identifies itself with a User-Agent.
+func NewProviderHTTPClient(cfg config.Config) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = cfg.ProviderMaxIdleConns
+	transport.MaxIdleConnsPerHost = cfg.ProviderMaxIdleConnsPerHost
+
+	if cfg.ProviderProxyURL != "" {
+		proxy, err := url.Parse(cfg.ProviderProxyURL)
+		if err != nil || proxy.Host == "" {
+			return nil, fmt.Errorf("invalid provider proxy URL %q", cfg.ProviderProxyURL)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	if cfg.ProviderCABundle != "" {
+		pem, err := os.ReadFile(cfg.ProviderCABundle)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read provider CA bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in provider CA bundle %q", cfg.ProviderCABundle)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+
+	var rt http.RoundTripper = transport
+	if cfg.ProviderUserAgent != "" {
+		rt = &userAgentTransport{next: transport, userAgent: cfg.ProviderUserAgent}
+	}
+	return &http.Client{Transport: rt, Timeout: cfg.ProviderHTTPTimeout}, nil
+}
+
+// userAgentTransport sets the User-Agent of every outgoing request.
+type userAgentTransport struct {
+	next      http.RoundTripper
+	userAgent string
+}
+
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// A RoundTripper must not modify the caller's request.
+	req = req.Clone(req.Context())
+	req.Header.Set("User-Agent", t.userAgent)
+	return t.next.RoundTrip(req)
+}
+
+// providerURL adds params to the query of base, keeping any parameters base
+// already has.
+func providerURL(base string, params url.Values) (string, error) {
+	u, err := url.Parse(base)
+	if err != nil {
+		return "", fmt.Errorf("invalid provider URL %q: %w", base, err)
+	}
+	query := u.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
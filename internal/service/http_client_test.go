@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/adal4ik/people-enrichment-service/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewProviderHTTPClient_SetsUserAgentAndTimeout(t *testing.T) {
+	var userAgent string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.UserAgent()
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	client, err := NewProviderHTTPClient(config.Config{ProviderHTTPTimeout: 3 * time.Second, ProviderUserAgent: "enricher-test/1.0"})
+	require.NoError(t, err)
+	assert.Equal(t, 3*time.Second, client.Timeout)
+
+	_, err = fetchJSON(context.Background(), client, "test", srv.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "enricher-test/1.0", userAgent)
+}
+
+func TestNewProviderHTTPClient_InvalidSettings(t *testing.T) {
+	_, err := NewProviderHTTPClient(config.Config{ProviderProxyURL: "not a proxy"})
+	assert.ErrorContains(t, err, "invalid provider proxy URL")
+
+	_, err = NewProviderHTTPClient(config.Config{ProviderCABundle: filepath.Join(t.TempDir(), "missing.pem")})
+	assert.ErrorContains(t, err, "failed to read provider CA bundle")
+
+	empty := filepath.Join(t.TempDir(), "empty.pem")
+	require.NoError(t, os.WriteFile(empty, []byte("not a certificate"), 0o600))
+	_, err = NewProviderHTTPClient(config.Config{ProviderCABundle: empty})
+	assert.ErrorContains(t, err, "no certificates found")
+}
+
+func TestProviderURL(t *testing.T) {
+	u, err := providerURL("https://api.example.com/v1?region=eu", url.Values{"name": {"Anna Maria&co"}})
+	require.NoError(t, err)
+	assert.Equal(t, "https://api.example.com/v1?name=Anna+Maria%26co&region=eu", u)
+}
@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/adal4ik/people-enrichment-service/internal/config"
+	"github.com/adal4ik/people-enrichment-service/internal/models"
+	"github.com/adal4ik/people-enrichment-service/internal/repository"
+	"github.com/adal4ik/people-enrichment-service/utils"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// reportTimeout bounds recording the outcome of a job, which must happen even
+// when the job itself was cancelled.
+const reportTimeout = 5 * time.Second
+
+// JobQueue enriches stored persons through the durable enrichment_jobs queue.
+// Unlike EnrichmentPool, queued work survives restarts and is shared by every
+// replica: failed jobs are retried with backoff, and jobs of a worker that
+// died are reclaimed once their visibility timeout expires.
+type JobQueue struct {
+	repo        repository.JobRepositoryInterface
+	handle      func(ctx context.Context, id uuid.UUID) error
+	workers     int
+	maxAttempts int
+	backoff     time.Duration
+	maxBackoff  time.Duration
+	visibility  time.Duration
+	poll        time.Duration
+	logger      *zap.Logger
+
+	// wake lets Submit start a local worker without waiting for the next poll.
+	wake chan struct{}
+
+	wg sync.WaitGroup
+	// stop ends claiming, cancelJobs the jobs still running.
+	stop       context.Context
+	cancelStop context.CancelFunc
+	jobs       context.Context
+	cancelJobs context.CancelFunc
+}
+
+func NewJobQueue(repo repository.JobRepositoryInterface, handle func(ctx context.Context, id uuid.UUID) error, cfg config.Config, logger *zap.Logger) *JobQueue {
+	return &JobQueue{
+		repo:        repo,
+		handle:      handle,
+		workers:     max(cfg.EnrichWorkers, 1),
+		maxAttempts: max(cfg.EnrichJobMaxAttempts, 1),
+		backoff:     cfg.EnrichJobBackoff,
+		maxBackoff:  cfg.EnrichJobMaxBackoff,
+		visibility:  cfg.EnrichJobVisibilityTimeout,
+		poll:        cfg.EnrichJobPollInterval,
+		logger:      logger,
+		wake:        make(chan struct{}, 1),
+	}
+}
+
+// Submit queues a person for enrichment.
+func (q *JobQueue) Submit(ctx context.Context, id uuid.UUID) error {
+	if err := q.repo.Enqueue(ctx, id); err != nil {
+		return err
+	}
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Start queues the pending persons that have no job yet, e.g. because the
+// process died right after storing them, and launches the workers.
+func (q *JobQueue) Start() {
+	q.stop, q.cancelStop = context.WithCancel(context.Background())
+	q.jobs, q.cancelJobs = context.WithCancel(context.Background())
+
+	if n, err := q.repo.EnqueuePending(q.stop); err != nil {
+		q.logger.Error("failed to queue pending persons", zap.Error(err))
+	} else if n > 0 {
+		q.logger.Info("queued pending persons", zap.Int64("count", n))
+	}
+
+	for i := 0; i < q.workers; i++ {
+		q.wg.Add(1)
+		go q.work(i)
+	}
+	q.logger.Info("enrichment queue workers started", zap.Int("workers", q.workers))
+}
+
+func (q *JobQueue) work(worker int) {
+	defer q.wg.Done()
+	for {
+		job, ok, err := q.repo.Claim(q.stop, q.visibility)
+		if q.stop.Err() != nil {
+			if ok {
+				q.release(job)
+			}
+			return
+		}
+		if err != nil {
+			q.logger.Error("failed to claim enrichment job", zap.Int("worker", worker), zap.Error(err))
+		}
+		if ok {
+			q.run(worker, job)
+			continue
+		}
+		select {
+		case <-q.stop.Done():
+			return
+		case <-q.wake:
+		case <-time.After(q.poll):
+		}
+	}
+}
+
+// run enriches the person of job and records the outcome: done, due again
+// after a backoff, failed once the attempts are used up, or handed back when
+// interrupted by a shutdown.
+func (q *JobQueue) run(worker int, job models.EnrichmentJob) {
+	log := q.logger.With(
+		zap.Int("worker", worker),
+		zap.Int64("job", job.ID),
+		zap.Any("id", job.PersonID),
+		zap.Int("attempt", job.Attempts),
+	)
+	if job.Attempts > q.maxAttempts {
+		// The worker of the last attempt died before reporting back.
+		q.report(log, "fail", func(ctx context.Context) error {
+			return q.repo.Fail(ctx, job, "enrichment did not finish within the visibility timeout")
+		})
+		log.Error("enrichment job abandoned")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(q.jobs, q.visibility)
+	err := q.handle(ctx, job.PersonID)
+	cancel()
+
+	switch {
+	case err == nil:
+		q.report(log, "complete", func(ctx context.Context) error { return q.repo.Complete(ctx, job) })
+	case q.jobs.Err() != nil:
+		q.release(job)
+	case job.Attempts >= q.maxAttempts:
+		q.report(log, "fail", func(ctx context.Context) error { return q.repo.Fail(ctx, job, err.Error()) })
+		log.Error("enrichment job failed", zap.Error(err))
+	default:
+		delay := q.delay(job.Attempts)
+		q.report(log, "retry", func(ctx context.Context) error { return q.repo.Retry(ctx, job, delay, err.Error()) })
+		log.Warn("enrichment job will be retried", zap.Duration("delay", delay), zap.Error(err))
+	}
+}
+
+func (q *JobQueue) release(job models.EnrichmentJob) {
+	log := q.logger.With(zap.Int64("job", job.ID), zap.Any("id", job.PersonID))
+	q.report(log, "release", func(ctx context.Context) error { return q.repo.Release(ctx, job) })
+}
+
+func (q *JobQueue) report(log *zap.Logger, action string, update func(ctx context.Context) error) {
+	ctx, cancel := context.WithTimeout(context.Background(), reportTimeout)
+	defer cancel()
+	err := update(ctx)
+	switch {
+	case errors.Is(err, utils.ErrJobLeaseLost):
+		log.Warn("enrichment job was reclaimed before it could "+action, zap.Error(err))
+	case err != nil:
+		log.Error("failed to "+action+" enrichment job", zap.Error(err))
+	}
+}
+
+// delay is the backoff before the attempt after attempt, doubling each time.
+func (q *JobQueue) delay(attempt int) time.Duration {
+	d := q.backoff << (attempt - 1)
+	if d <= 0 || d > q.maxBackoff {
+		d = q.maxBackoff
+	}
+	return max(d, 0)
+}
+
+// Shutdown stops claiming jobs and waits for the running ones to finish. Jobs
+// still running when ctx expires are cancelled and handed back to the queue.
+func (q *JobQueue) Shutdown(ctx context.Context) error {
+	q.cancelStop()
+
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		q.cancelJobs()
+		q.logger.Info("enrichment queue workers stopped")
+		return nil
+	case <-ctx.Done():
+		q.cancelJobs()
+		<-done
+		return ctx.Err()
+	}
+}
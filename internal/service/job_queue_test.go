@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/adal4ik/people-enrichment-service/internal/config"
+	"github.com/adal4ik/people-enrichment-service/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+// memoryJobRepo hands out queued jobs in order and records their outcome.
+type memoryJobRepo struct {
+	mu       sync.Mutex
+	queue    []models.EnrichmentJob
+	pending  int64
+	outcomes map[uuid.UUID]string
+	delays   []time.Duration
+	reasons  []string
+}
+
+func newMemoryJobRepo(jobs ...models.EnrichmentJob) *memoryJobRepo {
+	return &memoryJobRepo{queue: jobs, outcomes: make(map[uuid.UUID]string)}
+}
+
+func (m *memoryJobRepo) Enqueue(ctx context.Context, personID uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.queue = append(m.queue, models.EnrichmentJob{ID: int64(len(m.queue) + 1), PersonID: personID})
+	return nil
+}
+
+func (m *memoryJobRepo) EnqueuePending(ctx context.Context) (int64, error) {
+	return m.pending, nil
+}
+
+func (m *memoryJobRepo) Claim(ctx context.Context, visibility time.Duration) (models.EnrichmentJob, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.queue) == 0 {
+		return models.EnrichmentJob{}, false, ctx.Err()
+	}
+	job := m.queue[0]
+	m.queue = m.queue[1:]
+	job.Attempts++
+	job.Lease = uuid.New()
+	return job, true, nil
+}
+
+func (m *memoryJobRepo) record(job models.EnrichmentJob, outcome string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.outcomes[job.PersonID] = outcome
+}
+
+func (m *memoryJobRepo) Complete(ctx context.Context, job models.EnrichmentJob) error {
+	m.record(job, "done")
+	return nil
+}
+
+func (m *memoryJobRepo) Retry(ctx context.Context, job models.EnrichmentJob, delay time.Duration, reason string) error {
+	m.mu.Lock()
+	m.delays = append(m.delays, delay)
+	m.reasons = append(m.reasons, reason)
+	m.mu.Unlock()
+	m.record(job, "retry")
+	return nil
+}
+
+func (m *memoryJobRepo) Fail(ctx context.Context, job models.EnrichmentJob, reason string) error {
+	m.mu.Lock()
+	m.reasons = append(m.reasons, reason)
+	m.mu.Unlock()
+	m.record(job, "failed")
+	return nil
+}
+
+func (m *memoryJobRepo) Release(ctx context.Context, job models.EnrichmentJob) error {
+	m.record(job, "released")
+	return nil
+}
+
+func (m *memoryJobRepo) outcome(id uuid.UUID) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.outcomes[id]
+}
+
+func jobQueueConfig() config.Config {
+	return config.Config{
+		EnrichWorkers:              2,
+		EnrichJobMaxAttempts:       3,
+		EnrichJobBackoff:           time.Second,
+		EnrichJobMaxBackoff:        3 * time.Second,
+		EnrichJobVisibilityTimeout: time.Minute,
+		EnrichJobPollInterval:      time.Hour,
+	}
+}
+
+func TestJobQueue_SubmitWakesWorker(t *testing.T) {
+	repo := newMemoryJobRepo()
+	handled := make(chan uuid.UUID, 1)
+	queue := NewJobQueue(repo, func(ctx context.Context, id uuid.UUID) error {
+		handled <- id
+		return nil
+	}, jobQueueConfig(), zap.NewNop())
+	queue.Start()
+	defer queue.Shutdown(context.Background())
+
+	// The poll interval is an hour: only the wake-up gets the job picked up.
+	id := uuid.New()
+	assert.NoError(t, queue.Submit(context.Background(), id))
+	assert.Equal(t, id, <-handled)
+	assert.Eventually(t, func() bool { return repo.outcome(id) == "done" }, time.Second, time.Millisecond)
+}
+
+func TestJobQueue_RetriesThenFails(t *testing.T) {
+	retried, failed, abandoned := uuid.New(), uuid.New(), uuid.New()
+	repo := newMemoryJobRepo(
+		models.EnrichmentJob{ID: 1, PersonID: retried, Attempts: 1},
+		models.EnrichmentJob{ID: 2, PersonID: failed, Attempts: 2},
+		models.EnrichmentJob{ID: 3, PersonID: abandoned, Attempts: 3},
+	)
+	var (
+		mu    sync.Mutex
+		calls []uuid.UUID
+	)
+	cfg := jobQueueConfig()
+	cfg.EnrichWorkers = 1
+	queue := NewJobQueue(repo, func(ctx context.Context, id uuid.UUID) error {
+		mu.Lock()
+		calls = append(calls, id)
+		mu.Unlock()
+		return errors.New("agify: down")
+	}, cfg, zap.NewNop())
+	queue.Start()
+
+	assert.Eventually(t, func() bool { return repo.outcome(abandoned) != "" }, time.Second, time.Millisecond)
+	assert.NoError(t, queue.Shutdown(context.Background()))
+
+	assert.Equal(t, "retry", repo.outcome(retried))
+	assert.Equal(t, []time.Duration{2 * time.Second}, repo.delays)
+	assert.Equal(t, "failed", repo.outcome(failed))
+	assert.Equal(t, "failed", repo.outcome(abandoned))
+	assert.Equal(t, []uuid.UUID{retried, failed}, calls)
+	assert.Equal(t, "agify: down", repo.reasons[1])
+}
+
+func TestJobQueue_ShutdownDeadlineReleasesJobs(t *testing.T) {
+	id := uuid.New()
+	repo := newMemoryJobRepo(models.EnrichmentJob{ID: 1, PersonID: id})
+	started := make(chan struct{})
+	queue := NewJobQueue(repo, func(ctx context.Context, id uuid.UUID) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	}, jobQueueConfig(), zap.NewNop())
+	queue.Start()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, queue.Shutdown(ctx), context.DeadlineExceeded)
+	assert.Equal(t, "released", repo.outcome(id))
+}
+
+func TestJobQueue_Delay(t *testing.T) {
+	queue := NewJobQueue(newMemoryJobRepo(), nil, jobQueueConfig(), zap.NewNop())
+	assert.Equal(t, time.Second, queue.delay(1))
+	assert.Equal(t, 2*time.Second, queue.delay(2))
+	assert.Equal(t, 3*time.Second, queue.delay(3))
+	assert.Equal(t, 3*time.Second, queue.delay(70))
+}
+
+func TestEnrichQueued_LeavesPersonPending(t *testing.T) {
+	repo := new(mockPersonRepo)
+	svc := newEnrichingService(repo, config.Config{},
+		&stubEnricher{provider: "agify", attr: AttributeAge, err: errors.New("agify down")},
+	)
+	id := uuid.New()
+	repo.On("GetPerson", mock.Anything, id).
+		Return(models.Person{ID: id, Name: "John", EnrichmentStatus: models.EnrichmentPending}, nil)
+
+	err := svc.enrichQueued(context.Background(), id)
+	assert.ErrorContains(t, err, "agify down")
+	repo.AssertNotCalled(t, "UpdateEnrichment")
+}
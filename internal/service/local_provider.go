@@ -0,0 +1,298 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adal4ik/people-enrichment-service/internal/config"
+	"go.uber.org/zap"
+)
+
+// DatasetRecord holds the statistics of one name, optionally for a single
+// country. Records without a country answer queries for any country that has
+// no record of its own.
+type DatasetRecord struct {
+	Name              string           `json:"name"`
+	CountryID         string           `json:"country_id,omitempty"`
+	Age               *int             `json:"age,omitempty"`
+	Gender            string           `json:"gender,omitempty"`
+	GenderProbability *float64         `json:"gender_probability,omitempty"`
+	Count             *int             `json:"count,omitempty"`
+	Countries         []DatasetCountry `json:"countries,omitempty"`
+}
+
+// DatasetCountry is one entry of the nationality distribution of a name.
+type DatasetCountry struct {
+	CountryID   string  `json:"country_id"`
+	Probability float64 `json:"probability"`
+}
+
+type datasetKey struct {
+	name    string
+	country string
+}
+
+// Dataset is an in-memory name-statistics table used by the local provider.
+type Dataset struct {
+	records map[datasetKey]DatasetRecord
+}
+
+// LoadDataset reads a dataset from a .json file (an array of records) or a
+// .csv file with a header row. CSV columns are name, country_id, age, gender,
+// gender_probability, count and countries, the latter written as
+// "IT:0.61;US:0.12"; only name is required.
+func LoadDataset(path string) (*Dataset, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open dataset: %w", err)
+	}
+	defer f.Close()
+
+	var records []DatasetRecord
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		if err := json.NewDecoder(f).Decode(&records); err != nil {
+			return nil, fmt.Errorf("failed to decode dataset: %w", err)
+		}
+	case ".csv":
+		records, err = readDatasetCSV(f)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported dataset format %q", ext)
+	}
+	return NewDataset(records)
+}
+
+// NewDataset indexes records. Genders are normalized to the values persons
+// can be stored with, so "M" and "Female" are accepted; any other gender is
+// rejected.
+func NewDataset(records []DatasetRecord) (*Dataset, error) {
+	d := &Dataset{records: make(map[datasetKey]DatasetRecord, len(records))}
+	for i, rec := range records {
+		gender, err := datasetGender(rec.Gender)
+		if err != nil {
+			return nil, fmt.Errorf("invalid dataset record %d (%s): %w", i+1, rec.Name, err)
+		}
+		rec.Gender = gender
+		rec.CountryID = strings.ToUpper(strings.TrimSpace(rec.CountryID))
+		// Nationality is answered from the most likely country first.
+		slices.SortStableFunc(rec.Countries, func(a, b DatasetCountry) int {
+			switch {
+			case a.Probability > b.Probability:
+				return -1
+			case a.Probability < b.Probability:
+				return 1
+			}
+			return 0
+		})
+		d.records[datasetKey{normalizeName(rec.Name), rec.CountryID}] = rec
+	}
+	return d, nil
+}
+
+// datasetGender maps a dataset gender to male, female or other, the values of
+// the person_gender type. An empty gender stays unknown.
+func datasetGender(gender string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(gender)) {
+	case "":
+		return "", nil
+	case "m", "male":
+		return "male", nil
+	case "f", "female":
+		return "female", nil
+	case "o", "other":
+		return "other", nil
+	}
+	return "", fmt.Errorf("invalid gender %q", gender)
+}
+
+// Len returns the number of records.
+func (d *Dataset) Len() int {
+	return len(d.records)
+}
+
+// Lookup returns the record for name in country, falling back to the record
+// that is not tied to a country.
+func (d *Dataset) Lookup(name, country string) (DatasetRecord, bool) {
+	name = normalizeName(name)
+	if country != "" {
+		if rec, ok := d.records[datasetKey{name, country}]; ok {
+			return rec, true
+		}
+	}
+	rec, ok := d.records[datasetKey{name, ""}]
+	return rec, ok
+}
+
+func readDatasetCSV(r io.Reader) ([]DatasetRecord, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dataset header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, col := range header {
+		columns[strings.ToLower(strings.TrimSpace(col))] = i
+	}
+	if _, ok := columns["name"]; !ok {
+		return nil, errors.New("dataset has no name column")
+	}
+
+	var records []DatasetRecord
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read dataset: %w", err)
+		}
+		rec, err := parseDatasetRow(row, columns)
+		if err != nil {
+			return nil, fmt.Errorf("invalid dataset line %d: %w", line, err)
+		}
+		records = append(records, rec)
+	}
+}
+
+func parseDatasetRow(row []string, columns map[string]int) (DatasetRecord, error) {
+	field := func(name string) string {
+		if i, ok := columns[name]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	rec := DatasetRecord{
+		Name:      field("name"),
+		CountryID: field("country_id"),
+		Gender:    field("gender"),
+	}
+	if rec.Name == "" {
+		return DatasetRecord{}, errors.New("empty name")
+	}
+	if v := field("age"); v != "" {
+		age, err := strconv.Atoi(v)
+		if err != nil {
+			return DatasetRecord{}, fmt.Errorf("invalid age %q", v)
+		}
+		rec.Age = &age
+	}
+	if v := field("count"); v != "" {
+		count, err := strconv.Atoi(v)
+		if err != nil {
+			return DatasetRecord{}, fmt.Errorf("invalid count %q", v)
+		}
+		rec.Count = &count
+	}
+	if v := field("gender_probability"); v != "" {
+		probability, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return DatasetRecord{}, fmt.Errorf("invalid gender_probability %q", v)
+		}
+		rec.GenderProbability = &probability
+	}
+	if v := field("countries"); v != "" {
+		for _, item := range strings.Split(v, ";") {
+			id, p, ok := strings.Cut(strings.TrimSpace(item), ":")
+			probability, err := strconv.ParseFloat(p, 64)
+			if !ok || err != nil {
+				return DatasetRecord{}, fmt.Errorf("invalid country %q", item)
+			}
+			rec.Countries = append(rec.Countries, DatasetCountry{CountryID: strings.ToUpper(id), Probability: probability})
+		}
+	}
+	return rec, nil
+}
+
+var (
+	datasetsMu sync.Mutex
+	datasets   = make(map[string]*Dataset)
+)
+
+// sharedDataset loads the dataset at path once for all attributes.
+func sharedDataset(path string) (*Dataset, error) {
+	datasetsMu.Lock()
+	defer datasetsMu.Unlock()
+
+	if d, ok := datasets[path]; ok {
+		return d, nil
+	}
+	d, err := LoadDataset(path)
+	if err != nil {
+		return nil, err
+	}
+	datasets[path] = d
+	return d, nil
+}
+
+// localProvider answers from an in-memory dataset, without any network access.
+// Names missing from the dataset are unknown.
+type localProvider struct {
+	attr    Attribute
+	dataset *Dataset
+}
+
+func newLocalEnricher(attr Attribute, cfg config.Config, client HTTPDoer, logger *zap.Logger) (Enricher, error) {
+	if cfg.LocalDatasetPath == "" {
+		return nil, errors.New("local provider requires LOCAL_DATASET_PATH")
+	}
+	dataset, err := sharedDataset(cfg.LocalDatasetPath)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("local dataset loaded",
+		zap.String("attribute", string(attr)),
+		zap.String("path", cfg.LocalDatasetPath),
+		zap.Int("records", dataset.Len()),
+	)
+	return &localProvider{attr: attr, dataset: dataset}, nil
+}
+
+func (l *localProvider) Provider() string     { return "local" }
+func (l *localProvider) Attribute() Attribute { return l.attr }
+func (l *localProvider) InProcess()           {}
+
+func (l *localProvider) Enrich(ctx context.Context, q Query) (Result, error) {
+	res := Result{Attribute: l.attr, Provider: l.Provider(), FetchedAt: time.Now()}
+	rec, ok := l.dataset.Lookup(q.Name, q.CountryID)
+	if !ok {
+		return res, nil
+	}
+
+	res.SampleCount = rec.Count
+	switch l.attr {
+	case AttributeAge:
+		if rec.Age != nil {
+			res.Value = strconv.Itoa(*rec.Age)
+		}
+	case AttributeGender:
+		res.Value = rec.Gender
+		res.Probability = rec.GenderProbability
+	case AttributeNationality:
+		if len(rec.Countries) > 0 {
+			res.Value = rec.Countries[0].CountryID
+			res.Probability = &rec.Countries[0].Probability
+		}
+	}
+	evidence, err := json.Marshal(rec)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to encode dataset record: %w", err)
+	}
+	res.Evidence = evidence
+	return res, nil
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/adal4ik/people-enrichment-service/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestLoadDataset_CSVAndJSON(t *testing.T) {
+	for _, path := range []string{"testdata/names.csv", "testdata/names.json"} {
+		t.Run(filepath.Ext(path), func(t *testing.T) {
+			d, err := LoadDataset(path)
+			require.NoError(t, err)
+
+			rec, ok := d.Lookup(" ANDREA", "")
+			assert.True(t, ok)
+			assert.Equal(t, "female", rec.Gender)
+			assert.Equal(t, "IT", rec.Countries[0].CountryID)
+
+			rec, ok = d.Lookup("andrea", "IT")
+			assert.True(t, ok)
+			assert.Equal(t, "male", rec.Gender)
+
+			rec, ok = d.Lookup("andrea", "FR")
+			assert.True(t, ok)
+			assert.Equal(t, "female", rec.Gender)
+
+			_, ok = d.Lookup("nobody", "")
+			assert.False(t, ok)
+		})
+	}
+}
+
+func TestNewDataset_NormalizesGender(t *testing.T) {
+	d, err := NewDataset([]DatasetRecord{{Name: "Ann", Gender: "F"}, {Name: "Ivan", Gender: " Male"}, {Name: "Sam"}})
+	require.NoError(t, err)
+	for name, want := range map[string]string{"ann": "female", "ivan": "male", "sam": ""} {
+		rec, ok := d.Lookup(name, "")
+		assert.True(t, ok)
+		assert.Equal(t, want, rec.Gender, name)
+	}
+}
+
+func TestLoadDataset_Errors(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+
+	_, err := LoadDataset(write("names.txt", "name\nAnn\n"))
+	assert.ErrorContains(t, err, "unsupported dataset format")
+
+	_, err = LoadDataset(write("no_name.csv", "age\n30\n"))
+	assert.ErrorContains(t, err, "no name column")
+
+	_, err = LoadDataset(write("bad_age.csv", "name,age\nAnn,old\n"))
+	assert.ErrorContains(t, err, "invalid dataset line 2")
+
+	_, err = LoadDataset(write("bad_gender.json", `[{"name": "Ann", "gender": "woman"}]`))
+	assert.ErrorContains(t, err, `invalid dataset record 1 (Ann): invalid gender "woman"`)
+
+	_, err = LoadDataset(filepath.Join(dir, "missing.csv"))
+	assert.ErrorContains(t, err, "failed to open dataset")
+}
+
+func TestLocalProvider_Enrich(t *testing.T) {
+	cfg := config.Config{LocalDatasetPath: "testdata/names.csv"}
+	enricher := func(attr Attribute) Enricher {
+		e, err := newLocalEnricher(attr, cfg, nil, zap.NewNop())
+		require.NoError(t, err)
+		return e
+	}
+
+	res, err := enricher(AttributeAge).Enrich(context.Background(), Query{Name: "Dmitriy"})
+	assert.NoError(t, err)
+	assert.Equal(t, "38", res.Value)
+	assert.Equal(t, 22000, *res.SampleCount)
+	assert.NotEmpty(t, res.Evidence)
+
+	res, err = enricher(AttributeGender).Enrich(context.Background(), Query{Name: "Andrea", CountryID: "IT"})
+	assert.NoError(t, err)
+	assert.Equal(t, "male", res.Value)
+	assert.Equal(t, 0.98, *res.Probability)
+
+	res, err = enricher(AttributeNationality).Enrich(context.Background(), Query{Name: "Dmitriy"})
+	assert.NoError(t, err)
+	assert.Equal(t, "RU", res.Value)
+	assert.Equal(t, 0.55, *res.Probability)
+
+	res, err = enricher(AttributeAge).Enrich(context.Background(), Query{Name: "Nobody"})
+	assert.NoError(t, err)
+	assert.Empty(t, res.Value)
+	assert.Equal(t, "local", res.Provider)
+}
+
+func TestLocalProvider_RequiresPath(t *testing.T) {
+	_, err := newLocalEnricher(AttributeAge, config.Config{}, nil, zap.NewNop())
+	assert.ErrorContains(t, err, "LOCAL_DATASET_PATH")
+}
+
+func TestNewRegistryFromConfig_LocalProviderSkipsMiddlewares(t *testing.T) {
+	wrapped := 0
+	count := func(e Enricher) Enricher {
+		wrapped++
+		return e
+	}
+	cfg := config.Config{LocalDatasetPath: "testdata/names.csv", AgeProviders: []string{"local", "agify"}}
+	r, err := NewRegistryFromConfig(cfg, defaultClients, zap.NewNop(), count)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, wrapped)
+	assert.Equal(t, []string{"local", "agify"}, r.Providers())
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/adal4ik/people-enrichment-service/internal/repository"
+	"go.uber.org/zap"
+)
+
+// persistentCachedEnricher consults the shared name_enrichment_cache table before
+// calling the provider. Cache failures are logged and never fail the lookup.
+type persistentCachedEnricher struct {
+	Enricher
+	repo   repository.CacheRepositoryInterface
+	maxAge time.Duration
+	logger *zap.Logger
+}
+
+// WithPersistentCache returns a middleware that shares provider results across
+// replicas through Postgres. Entries older than maxAge are treated as missing.
+func WithPersistentCache(repo repository.CacheRepositoryInterface, maxAge time.Duration, logger *zap.Logger) EnricherMiddleware {
+	return func(e Enricher) Enricher {
+		return &persistentCachedEnricher{Enricher: e, repo: repo, maxAge: maxAge, logger: logger}
+	}
+}
+
+func (p *persistentCachedEnricher) Enrich(ctx context.Context, q Query) (Result, error) {
+	if res, ok := p.lookup(ctx, q); ok {
+		return res, nil
+	}
+	res, err := p.Enricher.Enrich(ctx, q)
+	if err != nil {
+		return Result{}, err
+	}
+	p.store(ctx, q, res)
+	return res, nil
+}
+
+func (p *persistentCachedEnricher) EnrichBatch(ctx context.Context, qs []Query) ([]Result, []error) {
+	results := make([]Result, len(qs))
+	errs := make([]error, len(qs))
+
+	var (
+		misses []Query
+		index  []int
+	)
+	for i, q := range qs {
+		if res, ok := p.lookup(ctx, q); ok {
+			results[i] = res
+			continue
+		}
+		misses = append(misses, q)
+		index = append(index, i)
+	}
+	if len(misses) == 0 {
+		return results, errs
+	}
+
+	missResults, missErrs := enrichBatch(ctx, p.Enricher, misses)
+	for j, i := range index {
+		results[i], errs[i] = missResults[j], missErrs[j]
+		if missErrs[j] == nil {
+			p.store(ctx, misses[j], missResults[j])
+		}
+	}
+	return results, errs
+}
+
+func (p *persistentCachedEnricher) lookup(ctx context.Context, q Query) (Result, bool) {
+	if cacheBypassed(ctx) {
+		return Result{}, false
+	}
+	cached, err := p.repo.GetCachedEnrichment(ctx, p.Provider(), normalizeName(q.Name), q.CountryID, time.Now().Add(-p.maxAge))
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			p.logger.Warn("failed to read persistent enrichment cache",
+				zap.String("provider", p.Provider()),
+				zap.Error(err),
+			)
+		}
+		return Result{}, false
+	}
+	return resultFromEnrichment(p.Attribute(), cached), true
+}
+
+func (p *persistentCachedEnricher) store(ctx context.Context, q Query, res Result) {
+	if err := p.repo.SaveCachedEnrichment(ctx, normalizeName(q.Name), q.CountryID, string(p.Attribute()), res.Enrichment()); err != nil {
+		p.logger.Warn("failed to write persistent enrichment cache",
+			zap.String("provider", p.Provider()),
+			zap.Error(err),
+		)
+	}
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/adal4ik/people-enrichment-service/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+type mockCacheRepo struct {
+	mock.Mock
+}
+
+func (m *mockCacheRepo) GetCachedEnrichment(ctx context.Context, provider, name, countryID string, since time.Time) (models.Enrichment, error) {
+	args := m.Called(ctx, provider, name, countryID, since)
+	return args.Get(0).(models.Enrichment), args.Error(1)
+}
+
+func (m *mockCacheRepo) SaveCachedEnrichment(ctx context.Context, name, countryID, attribute string, enrichment models.Enrichment) error {
+	args := m.Called(ctx, name, countryID, attribute, enrichment)
+	return args.Error(0)
+}
+
+func (m *mockCacheRepo) InvalidateCachedEnrichments(ctx context.Context, name, provider string) (int64, error) {
+	args := m.Called(ctx, name, provider)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func TestPersistentCache_Hit(t *testing.T) {
+	repo := new(mockCacheRepo)
+	inner := &countingEnricher{stubEnricher: stubEnricher{provider: "genderize", attr: AttributeGender, value: "male"}}
+	e := WithPersistentCache(repo, time.Hour, zap.NewNop())(inner)
+
+	repo.On("GetCachedEnrichment", mock.Anything, "genderize", "ivan", "", mock.Anything).
+		Return(models.Enrichment{Provider: "genderize", Value: ptr("male"), Probability: ptr(0.99)}, nil)
+
+	res, err := e.Enrich(context.Background(), Query{Name: " Ivan"})
+	assert.NoError(t, err)
+	assert.Equal(t, AttributeGender, res.Attribute)
+	assert.Equal(t, "male", res.Value)
+	assert.Equal(t, ptr(0.99), res.Probability)
+	assert.Equal(t, 0, inner.calls)
+	repo.AssertNotCalled(t, "SaveCachedEnrichment", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestPersistentCache_MissStoresResult(t *testing.T) {
+	repo := new(mockCacheRepo)
+	inner := &countingEnricher{stubEnricher: stubEnricher{provider: "agify", attr: AttributeAge, value: "33"}}
+	e := WithPersistentCache(repo, time.Hour, zap.NewNop())(inner)
+
+	repo.On("GetCachedEnrichment", mock.Anything, "agify", "ivan", "", mock.Anything).
+		Return(models.Enrichment{}, sql.ErrNoRows)
+	repo.On("SaveCachedEnrichment", mock.Anything, "ivan", "", "age", mock.MatchedBy(func(e models.Enrichment) bool {
+		return e.Provider == "agify" && *e.Value == "33"
+	})).Return(nil)
+
+	res, err := e.Enrich(context.Background(), Query{Name: "Ivan"})
+	assert.NoError(t, err)
+	assert.Equal(t, "33", res.Value)
+	assert.Equal(t, 1, inner.calls)
+	repo.AssertExpectations(t)
+}
+
+func TestPersistentCache_DatabaseErrorFallsThrough(t *testing.T) {
+	repo := new(mockCacheRepo)
+	inner := &countingEnricher{stubEnricher: stubEnricher{provider: "agify", attr: AttributeAge, value: "33"}}
+	e := WithPersistentCache(repo, time.Hour, zap.NewNop())(inner)
+
+	repo.On("GetCachedEnrichment", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(models.Enrichment{}, errors.New("connection refused"))
+	repo.On("SaveCachedEnrichment", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(errors.New("connection refused"))
+
+	res, err := e.Enrich(context.Background(), Query{Name: "Ivan"})
+	assert.NoError(t, err)
+	assert.Equal(t, "33", res.Value)
+	assert.Equal(t, 1, inner.calls)
+}
@@ -2,69 +2,191 @@ package service
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"net/http"
+	"strconv"
 
 	"github.com/adal4ik/people-enrichment-service/internal/config"
 	"github.com/adal4ik/people-enrichment-service/internal/models"
 	"github.com/adal4ik/people-enrichment-service/internal/repository"
+	"github.com/adal4ik/people-enrichment-service/utils"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
+// backfillBatchSize is how many persons BackfillNormalizedNames loads at once.
+const backfillBatchSize = 500
+
 type PersonServiceInterface interface {
-	CreatePerson(ctx context.Context, person models.Person) error
+	CreatePerson(ctx context.Context, person models.Person) (models.Person, error)
+	CreatePersons(ctx context.Context, persons []models.Person) []error
 	GetPersons(ctx context.Context, limit, offset, ageMin, ageMax int, name, surname, gender, nationality string) ([]models.Person, error)
 	GetPerson(ctx context.Context, id uuid.UUID) (models.Person, error)
 	DeletePerson(ctx context.Context, id uuid.UUID) error
 	UpdatePerson(ctx context.Context, person models.Person) error
+	ReEnrichPerson(ctx context.Context, id uuid.UUID, attrs []Attribute) (models.EnrichmentDiff, error)
+	PreviewEnrichment(ctx context.Context, person models.Person) (models.EnrichmentPreview, error)
 	GetAge(ctx context.Context, name string) (int, error)
 	GetGender(ctx context.Context, name string) (string, error)
 	GetNationality(ctx context.Context, name string) (string, error)
 }
 
 type PersonService struct {
-	repo   repository.PersonRepositoryInterface
-	cfg    config.Config
-	logger *zap.Logger
+	repo     repository.PersonRepositoryInterface
+	registry *Registry
+	names    *NameNormalizer
+	// thresholds reject weak answers; a missing entry accepts everything.
+	thresholds map[Attribute]Threshold
+	cfg        config.Config
+	logger     *zap.Logger
+	// pool is set in async and queue mode; persons are then stored first and
+	// enriched in the background.
+	pool enrichmentQueue
+}
+
+// enrichmentQueue hands persons stored as pending to background enrichment.
+type enrichmentQueue interface {
+	Submit(ctx context.Context, id uuid.UUID) error
 }
 
-func NewPersonService(repo repository.PersonRepositoryInterface, cfg config.Config, logger *zap.Logger) *PersonService {
+func NewPersonService(repo repository.PersonRepositoryInterface, registry *Registry, cfg config.Config, logger *zap.Logger) *PersonService {
+	// service.New rejects unknown standards; a nil normalizer only case-folds.
+	names, _ := NewNameNormalizer(cfg.NameTransliteration)
 	return &PersonService{
-		repo:   repo,
-		cfg:    cfg,
-		logger: logger,
+		repo:       repo,
+		registry:   registry,
+		names:      names,
+		thresholds: ThresholdsFromConfig(cfg),
+		cfg:        cfg,
+		logger:     logger,
+	}
+}
+
+// CreatePerson enriches and stores person. In async mode the person is stored
+// as pending and handed to the background workers instead; if it cannot be
+// queued it is removed again, so that no pending person is left behind.
+func (p *PersonService) CreatePerson(ctx context.Context, person models.Person) (models.Person, error) {
+	p.normalize(&person)
+	if p.pool != nil {
+		return p.createPending(ctx, person)
+	}
+
+	results, failures, err := p.enrich(ctx, p.queryFor(person))
+	if err != nil {
+		return models.Person{}, err
 	}
+	return p.save(ctx, person, results, failures)
 }
 
-func (p *PersonService) CreatePerson(ctx context.Context, person models.Person) error {
-	age, err := p.GetAge(ctx, person.Name)
+// createPending stores person as pending and queues it for enrichment.
+func (p *PersonService) createPending(ctx context.Context, person models.Person) (models.Person, error) {
+	person.EnrichmentStatus = models.EnrichmentPending
+	if err := p.repo.CreatePerson(ctx, person); err != nil {
+		return models.Person{}, err
+	}
+	if err := p.pool.Submit(ctx, person.ID); err != nil {
+		p.logger.Error("failed to queue person for enrichment", zap.Any("id", person.ID), zap.Error(err))
+		err = fmt.Errorf("%w: %w", utils.ErrEnrichmentNotQueued, err)
+		// The caller's context may be what cancelled the submit.
+		if deleteErr := p.repo.DeletePerson(context.WithoutCancel(ctx), person.ID); deleteErr != nil {
+			return models.Person{}, errors.Join(err, fmt.Errorf("failed to remove unqueued person: %w", deleteErr))
+		}
+		return models.Person{}, err
+	}
+	return person, nil
+}
+
+// save applies enrichment results to person and stores it with its evidence.
+// The person is stored first; failing to store the evidence afterwards is
+// only logged, since the person itself has been saved by then.
+func (p *PersonService) save(ctx context.Context, person models.Person, results []Result, failures map[Attribute]error) (models.Person, error) {
+	enrichments, err := p.collect(&person, results, failures)
 	if err != nil {
-		p.logger.Error("failed to get age", zap.Error(err))
-		return fmt.Errorf("failed to enrich age: %w", err)
+		return models.Person{}, err
+	}
+	if err := p.repo.CreatePerson(ctx, person); err != nil {
+		return models.Person{}, err
+	}
+	if err := p.saveEnrichments(ctx, person.ID, enrichments); err != nil {
+		p.logger.Error("person saved without enrichment details", zap.Any("id", person.ID), zap.Error(err))
+	}
+	return person, nil
+}
+
+func (p *PersonService) saveEnrichments(ctx context.Context, id uuid.UUID, enrichments map[string]models.Enrichment) error {
+	if len(enrichments) == 0 {
+		return nil
 	}
-	gender, err := p.GetGender(ctx, person.Name)
+	if err := p.repo.SaveEnrichments(ctx, id, enrichments); err != nil {
+		return fmt.Errorf("failed to save enrichment details: %w", err)
+	}
+	return nil
+}
+
+// abandonPending marks a person failed whose background enrichment was cut
+// short by a shutdown, unless it is no longer pending.
+func (p *PersonService) abandonPending(ctx context.Context, id uuid.UUID) error {
+	person, err := p.repo.GetPerson(ctx, id)
 	if err != nil {
-		p.logger.Error("failed to get gender", zap.Error(err))
-		return fmt.Errorf("failed to enrich gender: %w", err)
+		return fmt.Errorf("failed to load person: %w", err)
 	}
-	nationality, err := p.GetNationality(ctx, person.Name)
+	if person.EnrichmentStatus != models.EnrichmentPending {
+		return nil
+	}
+	msg := errEnrichmentInterrupted.Error()
+	person.EnrichmentStatus = models.EnrichmentFailed
+	person.EnrichmentError = &msg
+	return p.repo.UpdateEnrichment(ctx, person)
+}
+
+// enrichPending is run by the background workers for a person stored as pending.
+func (p *PersonService) enrichPending(ctx context.Context, id uuid.UUID) error {
+	return p.enrichStored(ctx, id, true)
+}
+
+// enrichQueued is run by the durable queue workers. A failure leaves the
+// person pending for the next attempt; the queue marks it failed once the
+// attempts run out.
+func (p *PersonService) enrichQueued(ctx context.Context, id uuid.UUID) error {
+	return p.enrichStored(ctx, id, false)
+}
+
+// enrichStored enriches a person stored as pending. When markFailed is set a
+// failed enrichment is recorded on the person.
+func (p *PersonService) enrichStored(ctx context.Context, id uuid.UUID, markFailed bool) error {
+	person, err := p.repo.GetPerson(ctx, id)
 	if err != nil {
-		p.logger.Error("failed to get nationality", zap.Error(err))
-		return fmt.Errorf("failed to enrich nationality: %w", err)
+		return fmt.Errorf("failed to load person: %w", err)
 	}
-	p.logger.Debug("enriched person data",
-		zap.Int("age", age),
-		zap.String("gender", gender),
-		zap.String("nationality", nationality),
-	)
 
-	person.Age = &age
-	person.Gender = &gender
-	person.Nationality = &nationality
+	results, failures, err := p.enrich(ctx, p.queryFor(person))
+	var enrichments map[string]models.Enrichment
+	if err == nil {
+		enrichments, err = p.collect(&person, results, failures)
+	}
+	if err != nil {
+		if ctx.Err() != nil || !markFailed {
+			// Shutting down or retried later: leave the person pending.
+			return err
+		}
+		msg := err.Error()
+		person.EnrichmentStatus = models.EnrichmentFailed
+		person.EnrichmentError = &msg
+		if updateErr := p.repo.UpdateEnrichment(ctx, person); updateErr != nil {
+			return errors.Join(err, updateErr)
+		}
+		return err
+	}
 
-	return p.repo.CreatePerson(ctx, person)
+	person.EnrichmentError = nil
+	if err := p.repo.UpdateEnrichment(ctx, person); err != nil {
+		return err
+	}
+	if err := p.saveEnrichments(ctx, person.ID, enrichments); err != nil {
+		return err
+	}
+	p.logger.Info("person enriched in background", zap.Any("id", id), zap.String("status", person.EnrichmentStatus))
+	return nil
 }
 
 func (p *PersonService) GetPersons(ctx context.Context, limit, offset, age_min, age_max int, name, surname, gender, nationality string) ([]models.Person, error) {
@@ -78,12 +200,27 @@ func (p *PersonService) GetPersons(ctx context.Context, limit, offset, age_min,
 		zap.String("gender", gender),
 		zap.String("nationality", nationality),
 	)
-	return p.repo.GetPersons(ctx, limit, offset, age_min, age_max, name, surname, gender, nationality)
+	var nameNormalized string
+	if name != "" {
+		nameNormalized = p.names.Normalize(name)
+	}
+	return p.repo.GetPersons(ctx, limit, offset, age_min, age_max, name, nameNormalized, surname, gender, nationality)
 }
 
 func (p *PersonService) GetPerson(ctx context.Context, id uuid.UUID) (models.Person, error) {
 	p.logger.Debug("getting person by id", zap.Any("id", id))
-	return p.repo.GetPerson(ctx, id)
+	person, err := p.repo.GetPerson(ctx, id)
+	if err != nil {
+		return models.Person{}, err
+	}
+	enrichments, err := p.repo.GetEnrichments(ctx, id)
+	if err != nil {
+		return models.Person{}, err
+	}
+	if len(enrichments) > 0 {
+		person.Enrichment = enrichments
+	}
+	return person, nil
 }
 
 func (p *PersonService) DeletePerson(ctx context.Context, id uuid.UUID) error {
@@ -93,90 +230,61 @@ func (p *PersonService) DeletePerson(ctx context.Context, id uuid.UUID) error {
 
 func (p *PersonService) UpdatePerson(ctx context.Context, person models.Person) error {
 	p.logger.Debug("updating person", zap.Any("person", person))
+	p.normalize(&person)
 	return p.repo.UpdatePerson(ctx, person)
 }
 
-func (p *PersonService) GetAge(ctx context.Context, name string) (int, error) {
-	url := fmt.Sprintf("%s?name=%s", p.cfg.APIAgeURL, name)
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-	if err != nil {
-		return 0, fmt.Errorf("failed to create request: %w", err)
-	}
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return 0, fmt.Errorf("failed to call agify API: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("agify API returned status %d", resp.StatusCode)
-	}
-	var result struct {
-		Age int `json:"age"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return 0, fmt.Errorf("failed to decode agify API response: %w", err)
+// BackfillNormalizedNames stores the normalized name of every person that has
+// none yet, such as Cyrillic names stored before normalization existed, and
+// returns how many were updated.
+func (p *PersonService) BackfillNormalizedNames(ctx context.Context) (int, error) {
+	updated := 0
+	for {
+		persons, err := p.repo.GetUnnormalizedPersons(ctx, backfillBatchSize)
+		if err != nil {
+			return updated, err
+		}
+		for _, person := range persons {
+			if err := p.repo.SetNameNormalized(ctx, person.ID, p.names.Normalize(person.Name)); err != nil {
+				return updated, err
+			}
+			updated++
+		}
+		if len(persons) < backfillBatchSize {
+			return updated, nil
+		}
 	}
+}
 
-	return result.Age, nil
+// normalize stores the normalized first name of person for searching.
+func (p *PersonService) normalize(person *models.Person) {
+	normalized := p.names.Normalize(person.Name)
+	person.NameNormalized = &normalized
 }
 
-func (p *PersonService) GetGender(ctx context.Context, name string) (string, error) {
-	url := fmt.Sprintf("%s?name=%s", p.cfg.APIGenderURL, name)
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
-	}
-	resp, err := http.DefaultClient.Do(req)
+func (p *PersonService) GetAge(ctx context.Context, name string) (int, error) {
+	res, err := p.registry.Enrich(ctx, AttributeAge, Query{Name: p.names.Normalize(name)})
 	if err != nil {
-		return "", fmt.Errorf("failed to call agify API: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("agify API returned status %d", resp.StatusCode)
-	}
-
-	var result struct {
-		Gender string `json:"gender"`
+		return 0, err
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", fmt.Errorf("failed to decode agify API response: %w", err)
+	if res.Value == "" {
+		return 0, nil
 	}
-	return result.Gender, nil
+	return strconv.Atoi(res.Value)
 }
 
-func (p *PersonService) GetNationality(ctx context.Context, name string) (string, error) {
-	url := fmt.Sprintf("%s?name=%s", p.cfg.APINationURL, name)
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+func (p *PersonService) GetGender(ctx context.Context, name string) (string, error) {
+	res, err := p.registry.Enrich(ctx, AttributeGender, Query{Name: p.names.Normalize(name)})
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return "", err
 	}
+	return res.Value, nil
+}
 
-	resp, err := http.DefaultClient.Do(req)
+func (p *PersonService) GetNationality(ctx context.Context, name string) (string, error) {
+	res, err := p.registry.Enrich(ctx, AttributeNationality, Query{Name: p.names.Normalize(name)})
 	if err != nil {
-		return "", fmt.Errorf("failed to call nationalize API: %w", err)
+		return "", err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("nationalize API returned status %d", resp.StatusCode)
-	}
-
-	var result struct {
-		Country []struct {
-			CountryID   string  `json:"country_id"`
-			Probability float64 `json:"probability"`
-		} `json:"country"`
-	}
-
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", fmt.Errorf("failed to decode nationalize API response: %w", err)
-	}
-
-	if len(result.Country) > 0 {
-		return result.Country[0].CountryID, nil
-	}
-
-	return "", nil
+	return res.Value, nil
 }
@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
+	"github.com/adal4ik/people-enrichment-service/internal/config"
 	"github.com/adal4ik/people-enrichment-service/internal/models"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -21,8 +23,8 @@ func (m *mockPersonRepo) CreatePerson(ctx context.Context, person models.Person)
 	return args.Error(0)
 }
 
-func (m *mockPersonRepo) GetPersons(ctx context.Context, limit, offset, ageMin, ageMax int, name, surname, gender, nationality string) ([]models.Person, error) {
-	args := m.Called(ctx, limit, offset, ageMin, ageMax, name, surname, gender, nationality)
+func (m *mockPersonRepo) GetPersons(ctx context.Context, limit, offset, ageMin, ageMax int, name, nameNormalized, surname, gender, nationality string) ([]models.Person, error) {
+	args := m.Called(ctx, limit, offset, ageMin, ageMax, name, nameNormalized, surname, gender, nationality)
 	return args.Get(0).([]models.Person), args.Error(1)
 }
 
@@ -180,7 +182,7 @@ func TestGetPersons(t *testing.T) {
 		{Name: "Alice"},
 		{Name: "Bob"},
 	}
-	repo.On("GetPersons", mock.Anything, 10, 0, 0, 100, "a", "b", "f", "US").Return(expected, nil)
+	repo.On("GetPersons", mock.Anything, 10, 0, 0, 100, "a", "a", "b", "f", "US").Return(expected, nil)
 
 	svc := &PersonService{repo: repo, logger: logger}
 	res, err := svc.GetPersons(context.Background(), 10, 0, 0, 100, "a", "b", "f", "US")
@@ -198,7 +200,7 @@ func TestGetPersons_Pagination(t *testing.T) {
 	}
 	limit := 2
 	offset := 5
-	repo.On("GetPersons", mock.Anything, limit, offset, 0, 100, "", "", "", "").Return(expected, nil)
+	repo.On("GetPersons", mock.Anything, limit, offset, 0, 100, "", "", "", "", "").Return(expected, nil)
 
 	svc := &PersonService{repo: repo, logger: logger}
 	res, err := svc.GetPersons(context.Background(), limit, offset, 0, 100, "", "", "", "")
@@ -213,7 +215,7 @@ func TestGetPersons_FilterByName(t *testing.T) {
 	expected := []models.Person{
 		{Name: "Charlie"},
 	}
-	repo.On("GetPersons", mock.Anything, 10, 0, 0, 100, "Charlie", "", "", "").Return(expected, nil)
+	repo.On("GetPersons", mock.Anything, 10, 0, 0, 100, "Charlie", "charlie", "", "", "").Return(expected, nil)
 
 	svc := &PersonService{repo: repo, logger: logger}
 	res, err := svc.GetPersons(context.Background(), 10, 0, 0, 100, "Charlie", "", "", "")
@@ -228,7 +230,7 @@ func TestGetPersons_FilterBySurname(t *testing.T) {
 	expected := []models.Person{
 		{Name: "David", Surname: "Smith"},
 	}
-	repo.On("GetPersons", mock.Anything, 10, 0, 0, 100, "", "Smith", "", "").Return(expected, nil)
+	repo.On("GetPersons", mock.Anything, 10, 0, 0, 100, "", "", "Smith", "", "").Return(expected, nil)
 
 	svc := &PersonService{repo: repo, logger: logger}
 	res, err := svc.GetPersons(context.Background(), 10, 0, 0, 100, "", "Smith", "", "")
@@ -244,7 +246,7 @@ func TestGetPersons_FilterByGender(t *testing.T) {
 	expected := []models.Person{
 		{Name: "Eve", Gender: &gender},
 	}
-	repo.On("GetPersons", mock.Anything, 10, 0, 0, 100, "", "", "female", "").Return(expected, nil)
+	repo.On("GetPersons", mock.Anything, 10, 0, 0, 100, "", "", "", "female", "").Return(expected, nil)
 
 	svc := &PersonService{repo: repo, logger: logger}
 	res, err := svc.GetPersons(context.Background(), 10, 0, 0, 100, "", "", "female", "")
@@ -260,7 +262,7 @@ func TestGetPersons_FilterByNationality(t *testing.T) {
 	expected := []models.Person{
 		{Name: "Frank", Nationality: &nation},
 	}
-	repo.On("GetPersons", mock.Anything, 10, 0, 0, 100, "", "", "", "DE").Return(expected, nil)
+	repo.On("GetPersons", mock.Anything, 10, 0, 0, 100, "", "", "", "", "DE").Return(expected, nil)
 
 	svc := &PersonService{repo: repo, logger: logger}
 	res, err := svc.GetPersons(context.Background(), 10, 0, 0, 100, "", "", "", "DE")
@@ -276,7 +278,7 @@ func TestGetPersons_FilterByAgeRange(t *testing.T) {
 	expected := []models.Person{
 		{Name: "Grace", Age: &age},
 	}
-	repo.On("GetPersons", mock.Anything, 10, 0, 25, 35, "", "", "", "").Return(expected, nil)
+	repo.On("GetPersons", mock.Anything, 10, 0, 25, 35, "", "", "", "", "").Return(expected, nil)
 
 	svc := &PersonService{repo: repo, logger: logger}
 	res, err := svc.GetPersons(context.Background(), 10, 0, 25, 35, "", "", "", "")
@@ -319,8 +321,10 @@ func TestUpdatePerson_Success(t *testing.T) {
 	age := 25
 	svc := &PersonService{repo: repo, logger: logger}
 	person := models.Person{Name: "Jane", Age: &age}
+	stored := person
+	stored.NameNormalized = ptr("jane")
 
-	repo.On("UpdatePerson", mock.Anything, person).Return(nil)
+	repo.On("UpdatePerson", mock.Anything, stored).Return(nil)
 
 	err := svc.UpdatePerson(context.Background(), person)
 	assert.NoError(t, err)
@@ -334,8 +338,10 @@ func TestUpdatePerson_Error(t *testing.T) {
 	svc := &PersonService{repo: repo, logger: logger}
 	person := models.Person{Name: "Jane", Age: &age}
 	expectedErr := errors.New("update error")
+	stored := person
+	stored.NameNormalized = ptr("jane")
 
-	repo.On("UpdatePerson", mock.Anything, person).Return(expectedErr)
+	repo.On("UpdatePerson", mock.Anything, stored).Return(expectedErr)
 
 	err := svc.UpdatePerson(context.Background(), person)
 	assert.Error(t, err)
@@ -348,6 +354,36 @@ func (m *mockPersonRepo) GetPerson(ctx context.Context, id uuid.UUID) (models.Pe
 	return args.Get(0).(models.Person), args.Error(1)
 }
 
+func (m *mockPersonRepo) UpdateEnrichment(ctx context.Context, person models.Person) error {
+	args := m.Called(ctx, person)
+	return args.Error(0)
+}
+
+func (m *mockPersonRepo) SaveEnrichments(ctx context.Context, personID uuid.UUID, enrichments map[string]models.Enrichment) error {
+	args := m.Called(ctx, personID, enrichments)
+	return args.Error(0)
+}
+
+func (m *mockPersonRepo) GetEnrichments(ctx context.Context, personID uuid.UUID) (map[string]models.Enrichment, error) {
+	args := m.Called(ctx, personID)
+	return args.Get(0).(map[string]models.Enrichment), args.Error(1)
+}
+
+func (m *mockPersonRepo) GetUnnormalizedPersons(ctx context.Context, limit int) ([]models.Person, error) {
+	args := m.Called(ctx, limit)
+	return args.Get(0).([]models.Person), args.Error(1)
+}
+
+func (m *mockPersonRepo) SetNameNormalized(ctx context.Context, id uuid.UUID, nameNormalized string) error {
+	args := m.Called(ctx, id, nameNormalized)
+	return args.Error(0)
+}
+
+func (m *mockPersonRepo) GetStalePersonIDs(ctx context.Context, olderThan time.Time, after uuid.UUID, limit int) ([]uuid.UUID, error) {
+	args := m.Called(ctx, olderThan, after, limit)
+	return args.Get(0).([]uuid.UUID), args.Error(1)
+}
+
 func TestGetPerson_Success(t *testing.T) {
 	repo := new(mockPersonRepo)
 	logger := zap.NewNop()
@@ -357,6 +393,7 @@ func TestGetPerson_Success(t *testing.T) {
 	expected := models.Person{Name: "Test", Age: &age}
 
 	repo.On("GetPerson", mock.Anything, id).Return(expected, nil)
+	repo.On("GetEnrichments", mock.Anything, id).Return(map[string]models.Enrichment{}, nil)
 
 	person, err := svc.GetPerson(context.Background(), id)
 	assert.NoError(t, err)
@@ -379,3 +416,260 @@ func TestGetPerson_Error(t *testing.T) {
 	assert.Equal(t, models.Person{}, person)
 	repo.AssertExpectations(t)
 }
+
+type blockingEnricher struct {
+	provider string
+	attr     Attribute
+}
+
+func (b *blockingEnricher) Provider() string     { return b.provider }
+func (b *blockingEnricher) Attribute() Attribute { return b.attr }
+
+func (b *blockingEnricher) Enrich(ctx context.Context, q Query) (Result, error) {
+	<-ctx.Done()
+	return Result{}, ctx.Err()
+}
+
+type slowEnricher struct {
+	stubEnricher
+	delay time.Duration
+}
+
+func (s *slowEnricher) Enrich(ctx context.Context, q Query) (Result, error) {
+	select {
+	case <-time.After(s.delay):
+		return s.stubEnricher.Enrich(ctx, q)
+	case <-ctx.Done():
+		return Result{}, ctx.Err()
+	}
+}
+
+func newEnrichingService(repo *mockPersonRepo, cfg config.Config, enrichers ...Enricher) *PersonService {
+	registry := NewRegistry()
+	for _, e := range enrichers {
+		registry.Register(e)
+	}
+	return NewPersonService(repo, registry, cfg, zap.NewNop())
+}
+
+func TestCreatePerson_EnrichesConcurrently(t *testing.T) {
+	repo := new(mockPersonRepo)
+	delay := 100 * time.Millisecond
+	svc := newEnrichingService(repo, config.Config{},
+		&slowEnricher{stubEnricher{provider: "agify", attr: AttributeAge, value: "74"}, delay},
+		&slowEnricher{stubEnricher{provider: "genderize", attr: AttributeGender, value: "male"}, delay},
+		&slowEnricher{stubEnricher{provider: "nationalize", attr: AttributeNationality, value: "NG"}, delay},
+	)
+
+	repo.On("CreatePerson", mock.Anything, mock.MatchedBy(func(p models.Person) bool {
+		return assert.Equal(t, ptr(74), p.Age) &&
+			assert.Equal(t, ptr("male"), p.Gender) &&
+			assert.Equal(t, ptr("NG"), p.Nationality)
+	})).Return(nil)
+	repo.On("SaveEnrichments", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	start := time.Now()
+	_, err := svc.CreatePerson(context.Background(), models.Person{Name: "John"})
+	assert.NoError(t, err)
+	assert.Less(t, time.Since(start), 2*delay)
+	repo.AssertExpectations(t)
+}
+
+func TestCreatePerson_PartialFailureCancelsSiblings(t *testing.T) {
+	repo := new(mockPersonRepo)
+	svc := newEnrichingService(repo, config.Config{},
+		&stubEnricher{provider: "agify", attr: AttributeAge, value: "30"},
+		&stubEnricher{provider: "genderize", attr: AttributeGender, err: errors.New("gender error")},
+		&blockingEnricher{provider: "nationalize", attr: AttributeNationality},
+	)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := svc.CreatePerson(context.Background(), models.Person{Name: "John"})
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to enrich gender")
+		assert.NotContains(t, err.Error(), "failed to enrich nationality")
+	case <-time.After(time.Second):
+		t.Fatal("blocked lookup was not cancelled after a sibling failed")
+	}
+	repo.AssertNotCalled(t, "CreatePerson", mock.Anything, mock.Anything)
+}
+
+func TestCreatePerson_AggregatesErrors(t *testing.T) {
+	repo := new(mockPersonRepo)
+	svc := newEnrichingService(repo, config.Config{},
+		&stubEnricher{provider: "agify", attr: AttributeAge, err: errors.New("age error")},
+		&stubEnricher{provider: "genderize", attr: AttributeGender, err: errors.New("gender error")},
+	)
+
+	_, err := svc.CreatePerson(context.Background(), models.Person{Name: "John"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "age error")
+	assert.Contains(t, err.Error(), "gender error")
+	repo.AssertNotCalled(t, "CreatePerson", mock.Anything, mock.Anything)
+}
+
+func TestCreatePerson_BestEffortSavesPartialPerson(t *testing.T) {
+	repo := new(mockPersonRepo)
+	svc := newEnrichingService(repo, config.Config{EnrichPolicy: PolicyBestEffort},
+		&stubEnricher{provider: "agify", attr: AttributeAge, value: "30"},
+		&stubEnricher{provider: "genderize", attr: AttributeGender, err: errors.New("gender error")},
+		&slowEnricher{stubEnricher{provider: "nationalize", attr: AttributeNationality, value: "NG"}, 20 * time.Millisecond},
+	)
+
+	repo.On("CreatePerson", mock.Anything, mock.MatchedBy(func(p models.Person) bool {
+		return assert.Equal(t, ptr(30), p.Age) &&
+			assert.Nil(t, p.Gender) &&
+			assert.Equal(t, ptr("NG"), p.Nationality) &&
+			assert.Equal(t, models.EnrichmentPartial, p.EnrichmentStatus)
+	})).Return(nil)
+	repo.On("SaveEnrichments", mock.Anything, mock.Anything, mock.MatchedBy(func(e map[string]models.Enrichment) bool {
+		return assert.Equal(t, "agify", e["age"].Provider) &&
+			assert.NotNil(t, e["gender"].Error) &&
+			assert.Contains(t, *e["gender"].Error, "gender error")
+	})).Return(nil)
+
+	person, err := svc.CreatePerson(context.Background(), models.Person{Name: "John"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"gender": "genderize: gender error"}, person.FailedAttributes())
+	repo.AssertExpectations(t)
+}
+
+func TestCreatePerson_KeepsPersonWhenEvidenceFails(t *testing.T) {
+	repo := new(mockPersonRepo)
+	svc := newEnrichingService(repo, config.Config{},
+		&stubEnricher{provider: "agify", attr: AttributeAge, value: "30"},
+	)
+
+	repo.On("CreatePerson", mock.Anything, mock.Anything).Return(nil)
+	repo.On("SaveEnrichments", mock.Anything, mock.Anything, mock.Anything).Return(errors.New("connection reset"))
+
+	person, err := svc.CreatePerson(context.Background(), models.Person{Name: "John"})
+	assert.NoError(t, err)
+	assert.Equal(t, ptr(30), person.Age)
+	repo.AssertExpectations(t)
+}
+
+func TestCreatePerson_BestEffortAllSucceeded(t *testing.T) {
+	repo := new(mockPersonRepo)
+	svc := newEnrichingService(repo, config.Config{EnrichPolicy: PolicyBestEffort},
+		&stubEnricher{provider: "agify", attr: AttributeAge, value: "30"},
+	)
+
+	repo.On("CreatePerson", mock.Anything, mock.MatchedBy(func(p models.Person) bool {
+		return p.EnrichmentStatus == models.EnrichmentCompleted
+	})).Return(nil)
+	repo.On("SaveEnrichments", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	person, err := svc.CreatePerson(context.Background(), models.Person{Name: "John"})
+	assert.NoError(t, err)
+	assert.Nil(t, person.FailedAttributes())
+}
+
+func TestCreatePerson_BestEffortInvalidValue(t *testing.T) {
+	repo := new(mockPersonRepo)
+	svc := newEnrichingService(repo, config.Config{EnrichPolicy: PolicyBestEffort},
+		&stubEnricher{provider: "agify", attr: AttributeAge, value: "old"},
+		&stubEnricher{provider: "genderize", attr: AttributeGender, value: "male"},
+	)
+
+	repo.On("CreatePerson", mock.Anything, mock.MatchedBy(func(p models.Person) bool {
+		return p.Age == nil && p.EnrichmentStatus == models.EnrichmentPartial
+	})).Return(nil)
+	repo.On("SaveEnrichments", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	person, err := svc.CreatePerson(context.Background(), models.Person{Name: "John"})
+	assert.NoError(t, err)
+	assert.Contains(t, person.FailedAttributes()["age"], "invalid age")
+}
+
+func TestCreatePerson_BestEffortCallerCancellation(t *testing.T) {
+	repo := new(mockPersonRepo)
+	svc := newEnrichingService(repo, config.Config{EnrichPolicy: PolicyBestEffort},
+		&stubEnricher{provider: "agify", attr: AttributeAge, value: "30"},
+		&blockingEnricher{provider: "genderize", attr: AttributeGender},
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+
+	_, err := svc.CreatePerson(ctx, models.Person{Name: "John"})
+	assert.ErrorIs(t, err, context.Canceled)
+	repo.AssertNotCalled(t, "CreatePerson", mock.Anything, mock.Anything)
+}
+
+func TestCreatePerson_EnrichTimeout(t *testing.T) {
+	repo := new(mockPersonRepo)
+	svc := newEnrichingService(repo, config.Config{EnrichTimeout: 50 * time.Millisecond},
+		&stubEnricher{provider: "agify", attr: AttributeAge, value: "30"},
+		&blockingEnricher{provider: "nationalize", attr: AttributeNationality},
+	)
+
+	_, err := svc.CreatePerson(context.Background(), models.Person{Name: "John"})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Contains(t, err.Error(), "failed to enrich nationality")
+	repo.AssertNotCalled(t, "CreatePerson", mock.Anything, mock.Anything)
+}
+
+func TestCreatePerson_CallerCancellation(t *testing.T) {
+	repo := new(mockPersonRepo)
+	svc := newEnrichingService(repo, config.Config{},
+		&blockingEnricher{provider: "agify", attr: AttributeAge},
+		&blockingEnricher{provider: "genderize", attr: AttributeGender},
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	_, err := svc.CreatePerson(ctx, models.Person{Name: "John"})
+	assert.ErrorIs(t, err, context.Canceled)
+	repo.AssertNotCalled(t, "CreatePerson", mock.Anything, mock.Anything)
+}
+
+func TestCreatePerson_SavesEnrichmentEvidence(t *testing.T) {
+	repo := new(mockPersonRepo)
+	svc := newEnrichingService(repo, config.Config{},
+		&stubEnricher{provider: "genderize", attr: AttributeGender, value: "female", probability: ptr(0.51), count: ptr(12)},
+	)
+	id := uuid.New()
+
+	repo.On("CreatePerson", mock.Anything, mock.Anything).Return(nil)
+	repo.On("SaveEnrichments", mock.Anything, id, mock.MatchedBy(func(e map[string]models.Enrichment) bool {
+		gender, ok := e["gender"]
+		return ok &&
+			assert.Equal(t, "genderize", gender.Provider) &&
+			assert.Equal(t, ptr("female"), gender.Value) &&
+			assert.Equal(t, ptr(0.51), gender.Probability) &&
+			assert.Equal(t, ptr(12), gender.SampleCount)
+	})).Return(nil)
+
+	created, err := svc.CreatePerson(context.Background(), models.Person{ID: id, Name: "Sasha"})
+	assert.NoError(t, err)
+	assert.Equal(t, models.EnrichmentCompleted, created.EnrichmentStatus)
+	repo.AssertExpectations(t)
+}
+
+func TestGetPerson_AttachesEnrichment(t *testing.T) {
+	repo := new(mockPersonRepo)
+	svc := &PersonService{repo: repo, logger: zap.NewNop()}
+	id := uuid.New()
+	enrichments := map[string]models.Enrichment{
+		"gender": {Provider: "genderize", Value: ptr("male"), Probability: ptr(0.99)},
+	}
+
+	repo.On("GetPerson", mock.Anything, id).Return(models.Person{ID: id, Name: "Ivan"}, nil)
+	repo.On("GetEnrichments", mock.Anything, id).Return(enrichments, nil)
+
+	person, err := svc.GetPerson(context.Background(), id)
+	assert.NoError(t, err)
+	assert.Equal(t, enrichments, person.Enrichment)
+	repo.AssertExpectations(t)
+}
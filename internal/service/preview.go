@@ -0,0 +1,40 @@
+package service
+
+import (
+	"context"
+
+	"github.com/adal4ik/people-enrichment-service/internal/models"
+)
+
+// PreviewEnrichment predicts the attributes of person through the same
+// providers, caches, rate limits and breakers as CreatePerson, without storing
+// anything. Attributes that fail are reported in the preview whatever the
+// enrichment policy, so that one unavailable provider does not hide the rest.
+func (p *PersonService) PreviewEnrichment(ctx context.Context, person models.Person) (models.EnrichmentPreview, error) {
+	bestEffort := *p
+	bestEffort.cfg.EnrichPolicy = PolicyBestEffort
+
+	p.normalize(&person)
+	results, failures, err := bestEffort.enrich(ctx, p.queryFor(person))
+	if err != nil {
+		return models.EnrichmentPreview{}, err
+	}
+	if _, err := bestEffort.collect(&person, results, failures); err != nil {
+		return models.EnrichmentPreview{}, err
+	}
+
+	return models.EnrichmentPreview{
+		Name:                    person.Name,
+		NameNormalized:          *person.NameNormalized,
+		Surname:                 person.Surname,
+		Patronymic:              person.Patronymic,
+		CountryHint:             person.CountryHint,
+		Age:                     person.Age,
+		Gender:                  person.Gender,
+		Nationality:             person.Nationality,
+		EnrichmentStatus:        person.EnrichmentStatus,
+		Enrichment:              person.Enrichment,
+		FailedAttributes:        person.FailedAttributes(),
+		LowConfidenceAttributes: person.LowConfidenceAttributes(),
+	}, nil
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/adal4ik/people-enrichment-service/internal/config"
+	"github.com/adal4ik/people-enrichment-service/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreviewEnrichment_DoesNotStore(t *testing.T) {
+	repo := new(mockPersonRepo)
+	svc := newEnrichingService(repo, config.Config{GenderMinProbability: 0.8, NameTransliteration: "icao"},
+		&stubEnricher{provider: "agify", attr: AttributeAge, value: "41", count: ptr(900)},
+		&stubEnricher{provider: "genderize", attr: AttributeGender, value: "male", probability: ptr(0.6)},
+		&stubEnricher{provider: "nationalize", attr: AttributeNationality, value: "RU", probability: ptr(0.5)},
+	)
+
+	preview, err := svc.PreviewEnrichment(context.Background(), models.Person{Name: " Дмитрий "})
+	assert.NoError(t, err)
+	assert.Equal(t, "dmitrii", preview.NameNormalized)
+	assert.Equal(t, ptr(41), preview.Age)
+	assert.Nil(t, preview.Gender)
+	assert.Equal(t, ptr("RU"), preview.Nationality)
+	assert.Equal(t, models.EnrichmentCompleted, preview.EnrichmentStatus)
+	assert.Equal(t, "agify", preview.Enrichment["age"].Provider)
+	assert.Equal(t, ptr(900), preview.Enrichment["age"].SampleCount)
+	assert.Equal(t, []string{"gender"}, preview.LowConfidenceAttributes)
+	repo.AssertNotCalled(t, "CreatePerson")
+	repo.AssertNotCalled(t, "SaveEnrichments")
+}
+
+func TestPreviewEnrichment_ReportsFailuresUnderStrictPolicy(t *testing.T) {
+	repo := new(mockPersonRepo)
+	svc := newEnrichingService(repo, config.Config{EnrichPolicy: PolicyStrict},
+		&stubEnricher{provider: "agify", attr: AttributeAge, value: "41"},
+		&stubEnricher{provider: "genderize", attr: AttributeGender, err: errors.New("down")},
+	)
+
+	preview, err := svc.PreviewEnrichment(context.Background(), models.Person{Name: "Dmitry"})
+	assert.NoError(t, err)
+	assert.Equal(t, ptr(41), preview.Age)
+	assert.Equal(t, models.EnrichmentPartial, preview.EnrichmentStatus)
+	assert.Equal(t, map[string]string{"gender": "genderize: down"}, preview.FailedAttributes)
+	assert.True(t, svc.strict())
+}
+
+func TestPreviewEnrichment_CallerCancellation(t *testing.T) {
+	svc := newEnrichingService(new(mockPersonRepo), config.Config{},
+		&blockingEnricher{provider: "agify", attr: AttributeAge},
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := svc.PreviewEnrichment(ctx, models.Person{Name: "Dmitry"})
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+
+	"github.com/adal4ik/people-enrichment-service/internal/models"
+	"github.com/adal4ik/people-enrichment-service/internal/repository"
+	"go.uber.org/zap"
+)
+
+type ProviderServiceInterface interface {
+	Status() []ProviderStatus
+	Quota() ([]RateLimitStatus, bool)
+	CacheStats() (CacheStats, bool)
+	Coalescing() ([]CoalescingStatus, bool)
+	Usage(ctx context.Context, days int) ([]models.ProviderUsage, bool, error)
+	InvalidateCache(ctx context.Context, name, provider string) (int64, error)
+}
+
+// ProviderService exposes operational state of the enrichment providers.
+type ProviderService struct {
+	registry  *Registry
+	cache     *ResultCache
+	cacheRepo repository.CacheRepositoryInterface
+	breakers  *BreakerSet
+	limiters  *RateLimiterSet
+	usage     *UsageTracker
+	coalescer *Coalescer
+	names     *NameNormalizer
+	logger    *zap.Logger
+}
+
+// ProviderStatus describes the health of a single enrichment provider.
+type ProviderStatus struct {
+	Provider   string         `json:"provider"`
+	Attributes []Attribute    `json:"attributes"`
+	Breaker    *BreakerStatus `json:"breaker,omitempty"`
+}
+
+func NewProviderService(registry *Registry, cache *ResultCache, cacheRepo repository.CacheRepositoryInterface, breakers *BreakerSet, limiters *RateLimiterSet, usage *UsageTracker, coalescer *Coalescer, names *NameNormalizer, logger *zap.Logger) *ProviderService {
+	return &ProviderService{
+		registry:  registry,
+		cache:     cache,
+		cacheRepo: cacheRepo,
+		breakers:  breakers,
+		limiters:  limiters,
+		usage:     usage,
+		coalescer: coalescer,
+		names:     names,
+		logger:    logger,
+	}
+}
+
+// Status reports every registered provider with its circuit breaker state.
+// In-process providers have no breaker.
+func (p *ProviderService) Status() []ProviderStatus {
+	var statuses []ProviderStatus
+	for _, provider := range p.registry.Providers() {
+		status := ProviderStatus{Provider: provider}
+		for _, attr := range Attributes {
+			for _, e := range p.registry.Enrichers(attr) {
+				if e.Provider() == provider {
+					status.Attributes = append(status.Attributes, attr)
+				}
+			}
+		}
+		if p.breakers != nil && !p.inProcess(provider) {
+			breaker := p.breakers.For(provider).Status()
+			status.Breaker = &breaker
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// Quota reports the last known quota per remote provider, or false if
+// limiting is disabled.
+func (p *ProviderService) Quota() ([]RateLimitStatus, bool) {
+	if p.limiters == nil {
+		return nil, false
+	}
+	var statuses []RateLimitStatus
+	for _, provider := range p.registry.Providers() {
+		if p.inProcess(provider) {
+			continue
+		}
+		statuses = append(statuses, p.limiters.For(provider).Status())
+	}
+	return statuses, true
+}
+
+// inProcess reports whether provider answers without network calls, and so
+// is neither guarded by a breaker nor rate limited.
+func (p *ProviderService) inProcess(provider string) bool {
+	for _, attr := range Attributes {
+		for _, e := range p.registry.Enrichers(attr) {
+			if e.Provider() == provider {
+				_, ok := e.(InProcessEnricher)
+				return ok
+			}
+		}
+	}
+	return false
+}
+
+// CacheStats reports the in-memory cache counters, or false if the cache is disabled.
+func (p *ProviderService) CacheStats() (CacheStats, bool) {
+	if p.cache == nil {
+		return CacheStats{}, false
+	}
+	return p.cache.Stats(), true
+}
+
+// Coalescing reports the lookups saved by sharing in-flight requests per
+// provider, or false if coalescing is disabled.
+func (p *ProviderService) Coalescing() ([]CoalescingStatus, bool) {
+	if p.coalescer == nil {
+		return nil, false
+	}
+	return p.coalescer.Status(), true
+}
+
+// Usage reports the daily request counters of the last days days per provider
+// and API key, or false if usage tracking is disabled.
+func (p *ProviderService) Usage(ctx context.Context, days int) ([]models.ProviderUsage, bool, error) {
+	if p.usage == nil {
+		return nil, false, nil
+	}
+	usage, err := p.usage.Usage(ctx, days)
+	return usage, true, err
+}
+
+// InvalidateCache drops cached answers for name from both the in-memory and the
+// persistent cache. An empty provider invalidates every provider. It returns the
+// number of persistent entries removed.
+func (p *ProviderService) InvalidateCache(ctx context.Context, name, provider string) (int64, error) {
+	// Lookups are cached under the normalized name, e.g. "dmitrii" for "Дмитрий".
+	name = p.names.Normalize(name)
+	providers := p.registry.Providers()
+	if provider != "" {
+		providers = []string{provider}
+	}
+	if p.cache != nil {
+		for _, prov := range providers {
+			key := cacheKey(prov, Query{Name: name})
+			p.cache.Delete(key)
+			// Localized answers are keyed per country.
+			p.cache.DeletePrefix(key + ":")
+		}
+	}
+	if p.cacheRepo == nil {
+		return 0, nil
+	}
+	removed, err := p.cacheRepo.InvalidateCachedEnrichments(ctx, normalizeName(name), provider)
+	if err != nil {
+		return 0, err
+	}
+	p.logger.Info("invalidated enrichment cache",
+		zap.String("name", name),
+		zap.String("provider", provider),
+		zap.Int64("removed", removed),
+	)
+	return removed, nil
+}
@@ -0,0 +1,32 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestProviderService_SkipsInProcessProviders(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(&stubEnricher{provider: "genderize", attr: AttributeGender})
+	registry.Register(rulesProvider{})
+	breakers := NewBreakerSet(3, time.Minute, zap.NewNop())
+	limiters := NewRateLimiterSet(0, 0, zap.NewNop())
+	svc := NewProviderService(registry, nil, nil, breakers, limiters, nil, nil, nil, zap.NewNop())
+
+	statuses := svc.Status()
+	assert.Len(t, statuses, 2)
+	for _, status := range statuses {
+		assert.Equal(t, status.Provider == "genderize", status.Breaker != nil, status.Provider)
+	}
+
+	quota, ok := svc.Quota()
+	assert.True(t, ok)
+	assert.Len(t, quota, 1)
+	assert.Equal(t, "genderize", quota[0].Provider)
+
+	assert.Len(t, breakers.breakers, 1)
+	assert.Len(t, limiters.limiters, 1)
+}
@@ -0,0 +1,229 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/adal4ik/people-enrichment-service/internal/config"
+	"go.uber.org/zap"
+)
+
+// maxBatchNames is the number of names the public APIs accept in one request.
+const maxBatchNames = 10
+
+// fetchJSON performs a GET request and returns the raw body of a 200 response.
+func fetchJSON(ctx context.Context, client HTTPDoer, api, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call %s API: %w", api, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s API returned status %d", api, resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s API response: %w", api, err)
+	}
+	return body, nil
+}
+
+// remoteProvider holds what agify, genderize and nationalize have in common:
+// the same query format and the same single/batch response shapes.
+type remoteProvider struct {
+	name    string
+	attr    Attribute
+	baseURL string
+	client  HTTPDoer
+	parse   func(body []byte) (Result, error)
+}
+
+func (r *remoteProvider) Provider() string     { return r.name }
+func (r *remoteProvider) Attribute() Attribute { return r.attr }
+
+func (r *remoteProvider) Enrich(ctx context.Context, q Query) (Result, error) {
+	params := url.Values{}
+	params.Set("name", q.Name)
+	if q.CountryID != "" {
+		params.Set("country_id", q.CountryID)
+	}
+	body, err := r.fetch(ctx, params)
+	if err != nil {
+		return Result{}, err
+	}
+	return r.result(body)
+}
+
+// EnrichBatch looks names up with multi-name requests (name[]=a&name[]=b),
+// at most maxBatchNames per request. A request carries a single country_id,
+// so queries are grouped by country first. Duplicate names are sent once.
+func (r *remoteProvider) EnrichBatch(ctx context.Context, qs []Query) ([]Result, []error) {
+	results := make([]Result, len(qs))
+	errs := make([]error, len(qs))
+
+	type key struct{ country, name string }
+	positions := make(map[key][]int)
+	var countries []string
+	names := make(map[string][]string)
+	for i, q := range qs {
+		k := key{q.CountryID, q.Name}
+		if _, ok := positions[k]; !ok {
+			if _, ok := names[q.CountryID]; !ok {
+				countries = append(countries, q.CountryID)
+			}
+			names[q.CountryID] = append(names[q.CountryID], q.Name)
+		}
+		positions[k] = append(positions[k], i)
+	}
+
+	for _, country := range countries {
+		group := names[country]
+		for start := 0; start < len(group); start += maxBatchNames {
+			chunk := group[start:min(start+maxBatchNames, len(group))]
+			chunkResults, err := r.fetchBatch(ctx, chunk, country)
+			for j, name := range chunk {
+				for _, i := range positions[key{country, name}] {
+					if err != nil {
+						errs[i] = err
+						continue
+					}
+					results[i] = chunkResults[j]
+				}
+			}
+		}
+	}
+	return results, errs
+}
+
+func (r *remoteProvider) fetchBatch(ctx context.Context, names []string, countryID string) ([]Result, error) {
+	params := url.Values{}
+	for _, name := range names {
+		params.Add("name[]", name)
+	}
+	if countryID != "" {
+		params.Set("country_id", countryID)
+	}
+	body, err := r.fetch(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	var items []json.RawMessage
+	if err := json.Unmarshal(body, &items); err != nil {
+		return nil, fmt.Errorf("failed to decode %s API batch response: %w", r.name, err)
+	}
+	if len(items) != len(names) {
+		return nil, fmt.Errorf("%s API returned %d results for %d names", r.name, len(items), len(names))
+	}
+	results := make([]Result, len(items))
+	for i, item := range items {
+		res, err := r.result(item)
+		if err != nil {
+			return nil, err
+		}
+		results[i] = res
+	}
+	return results, nil
+}
+
+func (r *remoteProvider) fetch(ctx context.Context, params url.Values) ([]byte, error) {
+	u, err := providerURL(r.baseURL, params)
+	if err != nil {
+		return nil, err
+	}
+	return fetchJSON(ctx, r.client, r.name, u)
+}
+
+func (r *remoteProvider) result(body []byte) (Result, error) {
+	res, err := r.parse(body)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to decode %s API response: %w", r.name, err)
+	}
+	res.Attribute = r.attr
+	res.Provider = r.name
+	res.Evidence = body
+	res.FetchedAt = time.Now()
+	return res, nil
+}
+
+func newAgifyEnricher(attr Attribute, cfg config.Config, client HTTPDoer, logger *zap.Logger) (Enricher, error) {
+	if attr != AttributeAge {
+		return nil, fmt.Errorf("agify does not provide %s", attr)
+	}
+	return &remoteProvider{name: "agify", attr: attr, baseURL: cfg.APIAgeURL, client: client, parse: parseAgify}, nil
+}
+
+func parseAgify(body []byte) (Result, error) {
+	var result struct {
+		Age   *int `json:"age"`
+		Count *int `json:"count"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return Result{}, err
+	}
+	res := Result{SampleCount: result.Count}
+	if result.Age != nil {
+		res.Value = strconv.Itoa(*result.Age)
+	}
+	return res, nil
+}
+
+func newGenderizeEnricher(attr Attribute, cfg config.Config, client HTTPDoer, logger *zap.Logger) (Enricher, error) {
+	if attr != AttributeGender {
+		return nil, fmt.Errorf("genderize does not provide %s", attr)
+	}
+	return &remoteProvider{name: "genderize", attr: attr, baseURL: cfg.APIGenderURL, client: client, parse: parseGenderize}, nil
+}
+
+func parseGenderize(body []byte) (Result, error) {
+	var result struct {
+		Gender      *string  `json:"gender"`
+		Probability *float64 `json:"probability"`
+		Count       *int     `json:"count"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return Result{}, err
+	}
+	res := Result{Probability: result.Probability, SampleCount: result.Count}
+	if result.Gender != nil {
+		res.Value = *result.Gender
+	}
+	return res, nil
+}
+
+func newNationalizeEnricher(attr Attribute, cfg config.Config, client HTTPDoer, logger *zap.Logger) (Enricher, error) {
+	if attr != AttributeNationality {
+		return nil, fmt.Errorf("nationalize does not provide %s", attr)
+	}
+	return &remoteProvider{name: "nationalize", attr: attr, baseURL: cfg.APINationURL, client: client, parse: parseNationalize}, nil
+}
+
+func parseNationalize(body []byte) (Result, error) {
+	var result struct {
+		Count   *int `json:"count"`
+		Country []struct {
+			CountryID   string  `json:"country_id"`
+			Probability float64 `json:"probability"`
+		} `json:"country"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return Result{}, err
+	}
+	res := Result{SampleCount: result.Count}
+	// Countries are ranked by probability, the first one is the best guess.
+	if len(result.Country) > 0 {
+		res.Value = result.Country[0].CountryID
+		res.Probability = &result.Country[0].Probability
+	}
+	return res, nil
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/adal4ik/people-enrichment-service/internal/config"
+	"github.com/adal4ik/people-enrichment-service/internal/fakeproviders"
+	"github.com/adal4ik/people-enrichment-service/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newFakeProviderService(t *testing.T, repo *mockPersonRepo, cfg config.Config, fake fakeproviders.Config) *PersonService {
+	t.Helper()
+	srv := httptest.NewServer(fakeproviders.New(fake))
+	t.Cleanup(srv.Close)
+
+	cfg.APIAgeURL = srv.URL + "/agify"
+	cfg.APIGenderURL = srv.URL + "/genderize"
+	cfg.APINationURL = srv.URL + "/nationalize"
+	cfg.AgeProviders = []string{"agify"}
+	cfg.GenderProviders = []string{"genderize"}
+	cfg.NationProviders = []string{"nationalize"}
+	registry, err := NewRegistryFromConfig(cfg, defaultClients, zap.NewNop())
+	require.NoError(t, err)
+	return NewPersonService(repo, registry, cfg, zap.NewNop())
+}
+
+func TestCreatePerson_WithFakeProviders(t *testing.T) {
+	age, probability := 37, 0.99
+	repo := new(mockPersonRepo)
+	svc := newFakeProviderService(t, repo, config.Config{}, fakeproviders.Config{Answers: map[string]fakeproviders.Answer{
+		"dmitriy": {Age: &age, Gender: "male", Probability: &probability, Countries: []fakeproviders.Country{{CountryID: "RU", Probability: 0.7}}},
+	}})
+	repo.On("CreatePerson", mock.Anything, mock.Anything).Return(nil)
+	repo.On("SaveEnrichments", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	person, err := svc.CreatePerson(context.Background(), models.Person{Name: "Dmitriy", Surname: "Ushakov"})
+	require.NoError(t, err)
+	assert.Equal(t, 37, *person.Age)
+	assert.Equal(t, "male", *person.Gender)
+	assert.Equal(t, "RU", *person.Nationality)
+	assert.Equal(t, models.EnrichmentCompleted, person.EnrichmentStatus)
+	assert.Equal(t, "genderize", person.Enrichment["gender"].Provider)
+}
+
+func TestCreatePerson_WithFailingFakeProvider(t *testing.T) {
+	repo := new(mockPersonRepo)
+	svc := newFakeProviderService(t, repo, config.Config{EnrichPolicy: PolicyBestEffort}, fakeproviders.Config{Answers: map[string]fakeproviders.Answer{
+		"anna": {Status: http.StatusBadGateway},
+	}})
+	repo.On("CreatePerson", mock.Anything, mock.Anything).Return(nil)
+	repo.On("SaveEnrichments", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	person, err := svc.CreatePerson(context.Background(), models.Person{Name: "Anna"})
+	require.NoError(t, err)
+	assert.Equal(t, models.EnrichmentPartial, person.EnrichmentStatus)
+	assert.Contains(t, person.FailedAttributes()["age"], "status 502")
+}
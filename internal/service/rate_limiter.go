@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/adal4ik/people-enrichment-service/utils"
+	"go.uber.org/zap"
+)
+
+// RateLimitStatus is the last known quota of a provider.
+type RateLimitStatus struct {
+	Provider  string     `json:"provider"`
+	Limit     *int       `json:"limit"`
+	Remaining *int       `json:"remaining"`
+	ResetAt   *time.Time `json:"reset_at,omitempty"`
+	Throttled uint64     `json:"throttled"`
+	Rejected  uint64     `json:"rejected"`
+}
+
+// RateLimiter tracks the X-Rate-Limit-* headers of a provider and holds back
+// requests once only reserve calls are left until the quota resets.
+type RateLimiter struct {
+	mu       sync.Mutex
+	provider string
+	reserve  int
+	maxWait  time.Duration
+	logger   *zap.Logger
+	now      func() time.Time
+	sleep    func(ctx context.Context, d time.Duration) error
+
+	known     bool
+	limit     int
+	remaining int
+	resetAt   time.Time
+	throttled uint64
+	rejected  uint64
+}
+
+func NewRateLimiter(provider string, reserve int, maxWait time.Duration, logger *zap.Logger) *RateLimiter {
+	return &RateLimiter{
+		provider: provider,
+		reserve:  reserve,
+		maxWait:  maxWait,
+		logger:   logger,
+		now:      time.Now,
+		sleep:    sleepContext,
+	}
+}
+
+// Acquire reserves one request, waiting for the quota to reset if needed.
+// It fails with utils.ErrQuotaExhausted when the reset is further away than maxWait.
+func (l *RateLimiter) Acquire(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		now := l.now()
+		if l.known && !now.Before(l.resetAt) {
+			// The window has rolled over, the next response tells us the new quota.
+			l.known = false
+		}
+		if !l.known || l.remaining > l.reserve {
+			if l.known {
+				l.remaining--
+			}
+			l.mu.Unlock()
+			return nil
+		}
+
+		wait := l.resetAt.Sub(now)
+		if wait > l.maxWait {
+			l.rejected++
+			l.mu.Unlock()
+			return fmt.Errorf("%s quota resets in %s: %w", l.provider, wait.Round(time.Second), utils.ErrQuotaExhausted)
+		}
+		l.throttled++
+		l.mu.Unlock()
+
+		l.logger.Info("throttling provider request until quota reset",
+			zap.String("provider", l.provider),
+			zap.Duration("wait", wait),
+		)
+		if err := l.sleep(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+// Observe updates the quota from a provider response.
+func (l *RateLimiter) Observe(resp *http.Response) {
+	limit, hasLimit := headerInt(resp.Header, "X-Rate-Limit-Limit")
+	remaining, hasRemaining := headerInt(resp.Header, "X-Rate-Limit-Remaining")
+	reset, hasReset := headerInt(resp.Header, "X-Rate-Limit-Reset")
+	if !hasReset {
+		if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			reset, hasReset = int(retryAfter/time.Second), true
+		}
+	}
+	if resp.StatusCode == http.StatusTooManyRequests && !hasRemaining {
+		remaining, hasRemaining = 0, true
+	}
+	if !hasRemaining || !hasReset {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.known = true
+	if hasLimit {
+		l.limit = limit
+	}
+	l.remaining = remaining
+	l.resetAt = l.now().Add(time.Duration(reset) * time.Second)
+}
+
+func (l *RateLimiter) Status() RateLimitStatus {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	status := RateLimitStatus{
+		Provider:  l.provider,
+		Throttled: l.throttled,
+		Rejected:  l.rejected,
+	}
+	if l.known {
+		limit, remaining, resetAt := l.limit, l.remaining, l.resetAt
+		status.Limit = &limit
+		status.Remaining = &remaining
+		status.ResetAt = &resetAt
+	}
+	return status
+}
+
+func headerInt(h http.Header, key string) (int, bool) {
+	n, err := strconv.Atoi(h.Get(key))
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+// RateLimiterSet holds one rate limiter per provider.
+type RateLimiterSet struct {
+	mu       sync.Mutex
+	reserve  int
+	maxWait  time.Duration
+	logger   *zap.Logger
+	limiters map[string]*RateLimiter
+}
+
+func NewRateLimiterSet(reserve int, maxWait time.Duration, logger *zap.Logger) *RateLimiterSet {
+	return &RateLimiterSet{
+		reserve:  reserve,
+		maxWait:  maxWait,
+		logger:   logger,
+		limiters: make(map[string]*RateLimiter),
+	}
+}
+
+func (s *RateLimiterSet) For(provider string) *RateLimiter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l, ok := s.limiters[provider]
+	if !ok {
+		l = NewRateLimiter(provider, s.reserve, s.maxWait, s.logger)
+		s.limiters[provider] = l
+	}
+	return l
+}
+
+// RateLimitedClient passes every request through a provider rate limiter.
+type RateLimitedClient struct {
+	client  HTTPDoer
+	limiter *RateLimiter
+}
+
+func NewRateLimitedClient(client HTTPDoer, limiter *RateLimiter) *RateLimitedClient {
+	return &RateLimitedClient{client: client, limiter: limiter}
+}
+
+func (c *RateLimitedClient) Do(req *http.Request) (*http.Response, error) {
+	if err := c.limiter.Acquire(req.Context()); err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	c.limiter.Observe(resp)
+	return resp, nil
+}
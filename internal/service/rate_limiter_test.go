@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/adal4ik/people-enrichment-service/utils"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func quotaServer(t *testing.T, limit, remaining, reset int) (*httptest.Server, *atomic.Int32) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(calls.Add(1))
+		w.Header().Set("X-Rate-Limit-Limit", strconv.Itoa(limit))
+		w.Header().Set("X-Rate-Limit-Remaining", strconv.Itoa(max(remaining-n, 0)))
+		w.Header().Set("X-Rate-Limit-Reset", strconv.Itoa(reset))
+		w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func TestRateLimitedClient_TracksHeaders(t *testing.T) {
+	srv, _ := quotaServer(t, 1000, 500, 3600)
+	limiter := NewRateLimiter("agify", 0, time.Second, zap.NewNop())
+	c := NewRateLimitedClient(http.DefaultClient, limiter)
+
+	resp, err := doGet(t, c, srv.URL)
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	status := limiter.Status()
+	assert.Equal(t, ptr(1000), status.Limit)
+	assert.Equal(t, ptr(499), status.Remaining)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), *status.ResetAt, 5*time.Second)
+}
+
+func TestRateLimitedClient_RejectsWhenQuotaExhausted(t *testing.T) {
+	srv, calls := quotaServer(t, 100, 2, 3600)
+	limiter := NewRateLimiter("agify", 0, time.Second, zap.NewNop())
+	c := NewRateLimitedClient(http.DefaultClient, limiter)
+
+	resp, err := doGet(t, c, srv.URL)
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	// One request is left and the limiter lets it through.
+	resp, err = doGet(t, c, srv.URL)
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	_, err = doGet(t, c, srv.URL)
+	assert.ErrorIs(t, err, utils.ErrQuotaExhausted)
+	assert.Equal(t, int32(2), calls.Load())
+	assert.Equal(t, uint64(1), limiter.Status().Rejected)
+}
+
+func TestRateLimiter_KeepsReserve(t *testing.T) {
+	limiter := NewRateLimiter("genderize", 10, time.Second, zap.NewNop())
+	limiter.Observe(&http.Response{Header: http.Header{
+		"X-Rate-Limit-Remaining": {"10"},
+		"X-Rate-Limit-Reset":     {"60"},
+	}})
+
+	assert.ErrorIs(t, limiter.Acquire(context.Background()), utils.ErrQuotaExhausted)
+}
+
+func TestRateLimiter_WaitsForReset(t *testing.T) {
+	now := time.Now()
+	limiter := NewRateLimiter("genderize", 0, time.Minute, zap.NewNop())
+	limiter.now = func() time.Time { return now }
+	var waited time.Duration
+	limiter.sleep = func(ctx context.Context, d time.Duration) error {
+		waited += d
+		now = now.Add(d)
+		return nil
+	}
+	limiter.Observe(&http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{
+		"Retry-After": {"30"},
+	}})
+
+	assert.NoError(t, limiter.Acquire(context.Background()))
+	assert.Equal(t, 30*time.Second, waited)
+	assert.Equal(t, uint64(1), limiter.Status().Throttled)
+}
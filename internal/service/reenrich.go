@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+
+	"github.com/adal4ik/people-enrichment-service/internal/models"
+	"github.com/adal4ik/people-enrichment-service/utils"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// ReEnrichPerson re-runs enrichment of attrs (every configured attribute when
+// empty) for the stored name of a person, bypassing cached provider answers,
+// and stores the outcome. Under the best-effort policy an attribute that fails
+// again keeps its previous value and is reported in the diff.
+func (p *PersonService) ReEnrichPerson(ctx context.Context, id uuid.UUID, attrs []Attribute) (models.EnrichmentDiff, error) {
+	if len(attrs) == 0 {
+		attrs = p.registry.Attributes()
+	}
+	for _, attr := range attrs {
+		if len(p.registry.Enrichers(attr)) == 0 {
+			return models.EnrichmentDiff{}, fmt.Errorf("%s: %w", attr, utils.ErrNoProvider)
+		}
+	}
+
+	before, err := p.GetPerson(ctx, id)
+	if err != nil {
+		return models.EnrichmentDiff{}, err
+	}
+
+	q := p.queryFor(before)
+	if q.CountryID == "" && p.cfg.EnrichLocalize && before.Nationality != nil && !slices.Contains(attrs, AttributeNationality) {
+		// Nationality is not being refreshed: localize with the stored one.
+		q.CountryID = *before.Nationality
+	}
+	results, failures, err := p.enrichAttributes(withoutCache(ctx), q, attrs)
+	if err != nil {
+		return models.EnrichmentDiff{}, err
+	}
+	return p.applyReEnrichment(ctx, before, attrs, results, failures)
+}
+
+// ReEnrichPersons re-runs enrichment of every configured attribute for the
+// persons ids like ReEnrichPerson does, but looks them up together with
+// multi-name provider requests. The returned diffs and errors are aligned
+// with ids.
+func (p *PersonService) ReEnrichPersons(ctx context.Context, ids []uuid.UUID) ([]models.EnrichmentDiff, []error) {
+	diffs := make([]models.EnrichmentDiff, len(ids))
+	errs := make([]error, len(ids))
+
+	var (
+		persons []models.Person
+		qs      []Query
+		found   []int
+	)
+	for i, id := range ids {
+		person, err := p.GetPerson(ctx, id)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		persons = append(persons, person)
+		qs = append(qs, p.queryFor(person))
+		found = append(found, i)
+	}
+	if len(qs) == 0 {
+		return diffs, errs
+	}
+
+	attrs := p.registry.Attributes()
+	enriched := p.EnrichBatch(withoutCache(ctx), qs)
+	for j, i := range found {
+		if err := ctx.Err(); err != nil {
+			errs[i] = err
+			continue
+		}
+		diffs[i], errs[i] = p.applyReEnrichment(ctx, persons[j], attrs, enriched[j].Results, enriched[j].Errors)
+	}
+	return diffs, errs
+}
+
+// applyReEnrichment stores the fresh lookups of attrs for the stored person
+// before and returns what changed.
+func (p *PersonService) applyReEnrichment(ctx context.Context, before models.Person, attrs []Attribute, results []Result, failures map[Attribute]error) (models.EnrichmentDiff, error) {
+	id := before.ID
+	after := before
+	after.Enrichment = nil
+	for _, res := range results {
+		if res.Value == "" {
+			// The provider no longer knows the name: drop the stale value.
+			clearAttribute(&after, res.Attribute)
+		}
+	}
+	refreshed, err := p.collect(&after, results, failures)
+	if err != nil {
+		return models.EnrichmentDiff{}, err
+	}
+
+	failed := make(map[string]string)
+	enrichments := make(map[string]models.Enrichment, len(before.Enrichment)+len(refreshed))
+	for attr, e := range before.Enrichment {
+		enrichments[attr] = e
+	}
+	for attr, e := range refreshed {
+		if e.Error != nil {
+			failed[attr] = *e.Error
+			if prev, ok := before.Enrichment[attr]; ok && prev.Error == nil {
+				delete(refreshed, attr)
+				continue
+			}
+		}
+		enrichments[attr] = e
+	}
+	after.Enrichment = enrichments
+	after.EnrichmentError = nil
+	after.EnrichmentStatus = models.EnrichmentCompleted
+	if len(after.FailedAttributes()) > 0 {
+		after.EnrichmentStatus = models.EnrichmentPartial
+	}
+
+	if err := p.repo.UpdateEnrichment(ctx, after); err != nil {
+		return models.EnrichmentDiff{}, err
+	}
+	if err := p.saveEnrichments(ctx, id, refreshed); err != nil {
+		return models.EnrichmentDiff{}, err
+	}
+
+	diff := models.EnrichmentDiff{
+		ID:               id,
+		EnrichmentStatus: after.EnrichmentStatus,
+		Changes:          make(map[string]models.FieldChange, len(attrs)),
+	}
+	for _, attr := range attrs {
+		old, cur := attributeValue(before, attr), attributeValue(after, attr)
+		diff.Changes[string(attr)] = models.FieldChange{
+			Before:  old,
+			After:   cur,
+			Changed: !reflect.DeepEqual(old, cur),
+		}
+	}
+	if len(failed) > 0 {
+		diff.FailedAttributes = failed
+	}
+	p.logger.Debug("re-enrichment diff", zap.Any("id", id), zap.Any("changes", diff.Changes))
+	return diff, nil
+}
+
+func clearAttribute(person *models.Person, attr Attribute) {
+	switch attr {
+	case AttributeAge:
+		person.Age = nil
+	case AttributeGender:
+		person.Gender = nil
+	case AttributeNationality:
+		person.Nationality = nil
+	}
+}
+
+func attributeValue(person models.Person, attr Attribute) interface{} {
+	switch attr {
+	case AttributeAge:
+		return person.Age
+	case AttributeGender:
+		return person.Gender
+	case AttributeNationality:
+		return person.Nationality
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/adal4ik/people-enrichment-service/internal/config"
+	"github.com/adal4ik/people-enrichment-service/internal/models"
+	"github.com/adal4ik/people-enrichment-service/utils"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func storedPerson(id uuid.UUID) models.Person {
+	return models.Person{
+		ID:               id,
+		Name:             "John",
+		Surname:          "Doe",
+		Age:              ptr(99),
+		Gender:           ptr("female"),
+		Nationality:      ptr("US"),
+		EnrichmentStatus: models.EnrichmentCompleted,
+	}
+}
+
+func TestReEnrichPerson_SelectedFields(t *testing.T) {
+	repo := new(mockPersonRepo)
+	age := &stubEnricher{provider: "agify", attr: AttributeAge, value: "30"}
+	gender := &countingEnricher{stubEnricher: stubEnricher{provider: "genderize", attr: AttributeGender, value: "male"}}
+	svc := newEnrichingService(repo, config.Config{}, age, gender)
+	id := uuid.New()
+
+	repo.On("GetPerson", mock.Anything, id).Return(storedPerson(id), nil)
+	repo.On("GetEnrichments", mock.Anything, id).Return(map[string]models.Enrichment{}, nil)
+	repo.On("UpdateEnrichment", mock.Anything, mock.MatchedBy(func(p models.Person) bool {
+		return assert.Equal(t, ptr(30), p.Age) &&
+			assert.Equal(t, ptr("female"), p.Gender) &&
+			assert.Equal(t, models.EnrichmentCompleted, p.EnrichmentStatus)
+	})).Return(nil)
+	repo.On("SaveEnrichments", mock.Anything, id, mock.MatchedBy(func(e map[string]models.Enrichment) bool {
+		_, hasGender := e["gender"]
+		return len(e) == 1 && e["age"].Provider == "agify" && !hasGender
+	})).Return(nil)
+
+	diff, err := svc.ReEnrichPerson(context.Background(), id, []Attribute{AttributeAge})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, gender.calls)
+	assert.Len(t, diff.Changes, 1)
+	assert.Equal(t, models.FieldChange{Before: ptr(99), After: ptr(30), Changed: true}, diff.Changes["age"])
+	repo.AssertExpectations(t)
+}
+
+func TestReEnrichPerson_UnknownClearsValue(t *testing.T) {
+	repo := new(mockPersonRepo)
+	svc := newEnrichingService(repo, config.Config{},
+		&stubEnricher{provider: "genderize", attr: AttributeGender},
+	)
+	id := uuid.New()
+
+	repo.On("GetPerson", mock.Anything, id).Return(storedPerson(id), nil)
+	repo.On("GetEnrichments", mock.Anything, id).Return(map[string]models.Enrichment{}, nil)
+	repo.On("UpdateEnrichment", mock.Anything, mock.MatchedBy(func(p models.Person) bool {
+		return p.Gender == nil
+	})).Return(nil)
+	repo.On("SaveEnrichments", mock.Anything, id, mock.Anything).Return(nil)
+
+	diff, err := svc.ReEnrichPerson(context.Background(), id, nil)
+	assert.NoError(t, err)
+	assert.True(t, diff.Changes["gender"].Changed)
+	assert.Nil(t, diff.Changes["gender"].After)
+}
+
+func TestReEnrichPerson_BestEffortKeepsPreviousValue(t *testing.T) {
+	repo := new(mockPersonRepo)
+	svc := newEnrichingService(repo, config.Config{EnrichPolicy: PolicyBestEffort},
+		&stubEnricher{provider: "agify", attr: AttributeAge, err: errors.New("age error")},
+		&stubEnricher{provider: "genderize", attr: AttributeGender, value: "male"},
+	)
+	id := uuid.New()
+	previous := map[string]models.Enrichment{"age": {Provider: "agify", Value: ptr("99")}}
+
+	repo.On("GetPerson", mock.Anything, id).Return(storedPerson(id), nil)
+	repo.On("GetEnrichments", mock.Anything, id).Return(previous, nil)
+	repo.On("UpdateEnrichment", mock.Anything, mock.MatchedBy(func(p models.Person) bool {
+		return assert.Equal(t, ptr(99), p.Age) &&
+			assert.Equal(t, ptr("male"), p.Gender) &&
+			assert.Equal(t, models.EnrichmentCompleted, p.EnrichmentStatus)
+	})).Return(nil)
+	repo.On("SaveEnrichments", mock.Anything, id, mock.MatchedBy(func(e map[string]models.Enrichment) bool {
+		_, hasAge := e["age"]
+		return !hasAge
+	})).Return(nil)
+
+	diff, err := svc.ReEnrichPerson(context.Background(), id, nil)
+	assert.NoError(t, err)
+	assert.False(t, diff.Changes["age"].Changed)
+	assert.Contains(t, diff.FailedAttributes["age"], "age error")
+	repo.AssertExpectations(t)
+}
+
+func TestReEnrichPerson_StrictFailureLeavesPersonUntouched(t *testing.T) {
+	repo := new(mockPersonRepo)
+	svc := newEnrichingService(repo, config.Config{},
+		&stubEnricher{provider: "agify", attr: AttributeAge, err: errors.New("age error")},
+	)
+	id := uuid.New()
+
+	repo.On("GetPerson", mock.Anything, id).Return(storedPerson(id), nil)
+	repo.On("GetEnrichments", mock.Anything, id).Return(map[string]models.Enrichment{}, nil)
+
+	_, err := svc.ReEnrichPerson(context.Background(), id, nil)
+	assert.ErrorContains(t, err, "age error")
+	repo.AssertNotCalled(t, "UpdateEnrichment", mock.Anything, mock.Anything)
+}
+
+func TestReEnrichPerson_NoProvider(t *testing.T) {
+	repo := new(mockPersonRepo)
+	svc := newEnrichingService(repo, config.Config{},
+		&stubEnricher{provider: "agify", attr: AttributeAge, value: "30"},
+	)
+
+	_, err := svc.ReEnrichPerson(context.Background(), uuid.New(), []Attribute{AttributeNationality})
+	assert.ErrorIs(t, err, utils.ErrNoProvider)
+	repo.AssertNotCalled(t, "GetPerson", mock.Anything, mock.Anything)
+}
+
+func TestReEnrichPerson_NotFound(t *testing.T) {
+	repo := new(mockPersonRepo)
+	svc := newEnrichingService(repo, config.Config{},
+		&stubEnricher{provider: "agify", attr: AttributeAge, value: "30"},
+	)
+	id := uuid.New()
+	repo.On("GetPerson", mock.Anything, id).Return(models.Person{}, sql.ErrNoRows)
+
+	_, err := svc.ReEnrichPerson(context.Background(), id, nil)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestParseAttributes(t *testing.T) {
+	attrs, err := ParseAttributes(" Age,gender,age,")
+	assert.NoError(t, err)
+	assert.Equal(t, []Attribute{AttributeAge, AttributeGender}, attrs)
+
+	attrs, err = ParseAttributes("")
+	assert.NoError(t, err)
+	assert.Empty(t, attrs)
+
+	_, err = ParseAttributes("age,height")
+	assert.ErrorIs(t, err, utils.ErrUnknownAttribute)
+}
+
+// batchCountingEnricher answers like stubEnricher and counts multi-name calls.
+type batchCountingEnricher struct {
+	stubEnricher
+	batches [][]string
+}
+
+func (b *batchCountingEnricher) EnrichBatch(ctx context.Context, qs []Query) ([]Result, []error) {
+	names := make([]string, len(qs))
+	results := make([]Result, len(qs))
+	errs := make([]error, len(qs))
+	for i, q := range qs {
+		names[i] = q.Name
+		results[i], errs[i] = b.Enrich(ctx, q)
+	}
+	b.batches = append(b.batches, names)
+	return results, errs
+}
+
+func TestReEnrichPersons_UsesBatchRequests(t *testing.T) {
+	repo := new(mockPersonRepo)
+	age := &batchCountingEnricher{stubEnricher: stubEnricher{provider: "agify", attr: AttributeAge, value: "30"}}
+	svc := newEnrichingService(repo, config.Config{}, age)
+	found, missing := uuid.New(), uuid.New()
+
+	repo.On("GetPerson", mock.Anything, found).Return(storedPerson(found), nil)
+	repo.On("GetPerson", mock.Anything, missing).Return(models.Person{}, utils.ErrPersonNotFound)
+	repo.On("GetEnrichments", mock.Anything, found).Return(map[string]models.Enrichment{}, nil)
+	repo.On("UpdateEnrichment", mock.Anything, mock.MatchedBy(func(p models.Person) bool {
+		return p.ID == found && *p.Age == 30
+	})).Return(nil)
+	repo.On("SaveEnrichments", mock.Anything, found, mock.Anything).Return(nil)
+
+	diffs, errs := svc.ReEnrichPersons(context.Background(), []uuid.UUID{found, missing})
+	assert.NoError(t, errs[0])
+	assert.Equal(t, models.FieldChange{Before: ptr(99), After: ptr(30), Changed: true}, diffs[0].Changes["age"])
+	assert.ErrorIs(t, errs[1], utils.ErrPersonNotFound)
+	assert.Equal(t, [][]string{{"john"}}, age.batches)
+	repo.AssertExpectations(t)
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/adal4ik/people-enrichment-service/utils"
+	"go.uber.org/zap"
+)
+
+// HTTPDoer is the part of *http.Client used by enrichment providers.
+type HTTPDoer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// ClientFactory returns the HTTP client a provider should use for outbound calls.
+type ClientFactory func(provider string) HTTPDoer
+
+// RetryPolicy controls how RetryClient retries failed requests.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// RetryClient retries transient failures (transport errors, 429 and 5xx
+// responses) with exponential backoff and jitter, honoring Retry-After.
+type RetryClient struct {
+	client HTTPDoer
+	policy RetryPolicy
+	logger *zap.Logger
+	sleep  func(ctx context.Context, d time.Duration) error
+}
+
+func NewRetryClient(client HTTPDoer, policy RetryPolicy, logger *zap.Logger) *RetryClient {
+	if policy.MaxAttempts < 1 {
+		policy.MaxAttempts = 1
+	}
+	return &RetryClient{
+		client: client,
+		policy: policy,
+		logger: logger,
+		sleep:  sleepContext,
+	}
+}
+
+func (c *RetryClient) Do(req *http.Request) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, fmt.Errorf("failed to rewind request body: %w", err)
+			}
+			req.Body = body
+		}
+
+		resp, err := c.client.Do(req)
+		if !c.shouldRetry(req, resp, err) || attempt >= c.policy.MaxAttempts {
+			return resp, err
+		}
+
+		delay := c.backoff(attempt)
+		if resp != nil {
+			if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+				if retryAfter > c.policy.MaxDelay {
+					// The provider asks us to wait longer than we are willing to.
+					return resp, nil
+				}
+				delay = retryAfter
+			}
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		c.logger.Warn("retrying provider request",
+			zap.String("host", req.URL.Host),
+			zap.Int("attempt", attempt),
+			zap.Duration("delay", delay),
+			zap.Any("status", statusOf(resp)),
+			zap.Error(err),
+		)
+		if err := c.sleep(req.Context(), delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (c *RetryClient) shouldRetry(req *http.Request, resp *http.Response, err error) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	if err != nil {
+		// Cancellation by the caller is final, everything else on the wire
+		// (resets, timeouts, refused connections) is worth another try.
+		return req.Context().Err() == nil &&
+			!errors.Is(err, context.Canceled) &&
+			!errors.Is(err, utils.ErrQuotaExhausted)
+	}
+	return isRetryableStatus(resp.StatusCode)
+}
+
+// backoff returns the exponential delay for attempt with equal jitter.
+func (c *RetryClient) backoff(attempt int) time.Duration {
+	d := c.policy.BaseDelay << (attempt - 1)
+	if d <= 0 || d > c.policy.MaxDelay {
+		d = c.policy.MaxDelay
+	}
+	if d <= 0 {
+		return 0
+	}
+	half := d / 2
+	return half + rand.N(half+1)
+}
+
+func isRetryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests ||
+		code == http.StatusRequestTimeout ||
+		(code >= 500 && code != http.StatusNotImplemented)
+}
+
+// parseRetryAfter understands both delay-seconds and HTTP-date values.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(time.Until(t), 0), true
+	}
+	return 0, false
+}
+
+func statusOf(resp *http.Response) *int {
+	if resp == nil {
+		return nil
+	}
+	return &resp.StatusCode
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/adal4ik/people-enrichment-service/internal/config"
+	"github.com/adal4ik/people-enrichment-service/internal/models"
+	"github.com/adal4ik/people-enrichment-service/internal/repository"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// refreshLockKey is the Postgres advisory lock that keeps stale re-enrichment
+// to a single replica at a time.
+const refreshLockKey int64 = 0x7065726e73 // "perns"
+
+// RefreshSummary describes one stale re-enrichment run.
+type RefreshSummary struct {
+	Selected  int
+	Refreshed int
+	Changed   int
+	Failed    int
+	Duration  time.Duration
+}
+
+// RefreshScheduler periodically re-enriches persons whose enrichment is older
+// than the configured maximum age. Each batch of stale persons is refreshed at
+// once, so that providers receive multi-name requests.
+type RefreshScheduler struct {
+	repo    repository.PersonRepositoryInterface
+	locks   repository.LockRepositoryInterface
+	refresh func(ctx context.Context, ids []uuid.UUID) ([]models.EnrichmentDiff, []error)
+	logger  *zap.Logger
+
+	interval   time.Duration
+	maxAge     time.Duration
+	batchSize  int
+	batchDelay time.Duration
+	now        func() time.Time
+	sleep      func(ctx context.Context, d time.Duration) error
+
+	wg     sync.WaitGroup
+	cancel context.CancelFunc
+}
+
+func NewRefreshScheduler(repo repository.PersonRepositoryInterface, locks repository.LockRepositoryInterface, refresh func(ctx context.Context, ids []uuid.UUID) ([]models.EnrichmentDiff, []error), cfg config.Config, logger *zap.Logger) *RefreshScheduler {
+	return &RefreshScheduler{
+		repo:       repo,
+		locks:      locks,
+		refresh:    refresh,
+		logger:     logger,
+		interval:   cfg.RefreshInterval,
+		maxAge:     cfg.RefreshMaxAge,
+		batchSize:  max(cfg.RefreshBatchSize, 1),
+		batchDelay: cfg.RefreshBatchDelay,
+		now:        time.Now,
+		sleep:      sleepContext,
+	}
+}
+
+// Start runs the scheduler every interval until Shutdown is called.
+func (s *RefreshScheduler) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if _, _, err := s.RunOnce(ctx); err != nil && ctx.Err() == nil {
+					s.logger.Error("stale re-enrichment failed", zap.Error(err))
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	s.logger.Info("stale re-enrichment scheduled",
+		zap.Duration("interval", s.interval),
+		zap.Duration("max_age", s.maxAge),
+	)
+}
+
+// Shutdown interrupts a running pass and waits for the scheduler to stop.
+func (s *RefreshScheduler) Shutdown(ctx context.Context) error {
+	s.cancel()
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// RunOnce re-enriches every stale person in batches, pausing between batches
+// to spread provider calls. It reports false when another replica holds the
+// lock and nothing was done.
+func (s *RefreshScheduler) RunOnce(ctx context.Context) (RefreshSummary, bool, error) {
+	release, acquired, err := s.locks.TryLock(ctx, refreshLockKey)
+	if err != nil {
+		return RefreshSummary{}, false, err
+	}
+	if !acquired {
+		s.logger.Debug("stale re-enrichment is running on another replica")
+		return RefreshSummary{}, false, nil
+	}
+	defer release()
+
+	start := s.now()
+	olderThan := start.Add(-s.maxAge)
+	var (
+		summary RefreshSummary
+		after   uuid.UUID
+	)
+	for {
+		ids, err := s.repo.GetStalePersonIDs(ctx, olderThan, after, s.batchSize)
+		if err != nil {
+			return summary, true, err
+		}
+		summary.Selected += len(ids)
+		diffs, errs := s.refresh(ctx, ids)
+		if ctx.Err() != nil {
+			return summary, true, ctx.Err()
+		}
+		for i, id := range ids {
+			if err := errs[i]; err != nil {
+				summary.Failed++
+				s.logger.Warn("failed to re-enrich stale person", zap.Any("id", id), zap.Error(err))
+				continue
+			}
+			summary.Refreshed++
+			for _, change := range diffs[i].Changes {
+				if change.Changed {
+					summary.Changed++
+					break
+				}
+			}
+		}
+		if len(ids) < s.batchSize {
+			break
+		}
+		after = ids[len(ids)-1]
+		if err := s.sleep(ctx, s.batchDelay); err != nil {
+			return summary, true, err
+		}
+	}
+	summary.Duration = s.now().Sub(start)
+
+	s.logger.Info("stale re-enrichment finished",
+		zap.Int("selected", summary.Selected),
+		zap.Int("refreshed", summary.Refreshed),
+		zap.Int("changed", summary.Changed),
+		zap.Int("failed", summary.Failed),
+		zap.Duration("duration", summary.Duration),
+	)
+	return summary, true, nil
+}
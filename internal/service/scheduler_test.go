@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/adal4ik/people-enrichment-service/internal/config"
+	"github.com/adal4ik/people-enrichment-service/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+type fakeLocks struct {
+	held     bool
+	released int
+}
+
+func (f *fakeLocks) TryLock(ctx context.Context, key int64) (func(), bool, error) {
+	if f.held {
+		return nil, false, nil
+	}
+	f.held = true
+	return func() { f.held = false; f.released++ }, true, nil
+}
+
+func newTestScheduler(repo *mockPersonRepo, locks *fakeLocks, refresh func(ctx context.Context, ids []uuid.UUID) ([]models.EnrichmentDiff, []error)) (*RefreshScheduler, *[]time.Duration) {
+	s := NewRefreshScheduler(repo, locks, refresh, config.Config{
+		RefreshMaxAge:     time.Hour,
+		RefreshBatchSize:  2,
+		RefreshBatchDelay: time.Second,
+	}, zap.NewNop())
+	now := time.Date(2025, 6, 19, 12, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+	var sleeps []time.Duration
+	s.sleep = func(ctx context.Context, d time.Duration) error {
+		sleeps = append(sleeps, d)
+		return nil
+	}
+	return s, &sleeps
+}
+
+func TestRefreshScheduler_RunsInBatches(t *testing.T) {
+	repo := new(mockPersonRepo)
+	locks := &fakeLocks{}
+	ids := []uuid.UUID{uuid.New(), uuid.New(), uuid.New()}
+	olderThan := time.Date(2025, 6, 19, 11, 0, 0, 0, time.UTC)
+
+	repo.On("GetStalePersonIDs", mock.Anything, olderThan, uuid.Nil, 2).Return(ids[:2], nil)
+	repo.On("GetStalePersonIDs", mock.Anything, olderThan, ids[1], 2).Return(ids[2:], nil)
+
+	var batches [][]uuid.UUID
+	refresh := func(ctx context.Context, batch []uuid.UUID) ([]models.EnrichmentDiff, []error) {
+		batches = append(batches, batch)
+		diffs := make([]models.EnrichmentDiff, len(batch))
+		errs := make([]error, len(batch))
+		for i, id := range batch {
+			switch id {
+			case ids[0]:
+				diffs[i] = models.EnrichmentDiff{Changes: map[string]models.FieldChange{"age": {Changed: true}}}
+			case ids[1]:
+				errs[i] = errors.New("provider down")
+			default:
+				diffs[i] = models.EnrichmentDiff{Changes: map[string]models.FieldChange{"age": {}}}
+			}
+		}
+		return diffs, errs
+	}
+	s, sleeps := newTestScheduler(repo, locks, refresh)
+
+	summary, ran, err := s.RunOnce(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, ran)
+	assert.Equal(t, [][]uuid.UUID{ids[:2], ids[2:]}, batches)
+	assert.Equal(t, RefreshSummary{Selected: 3, Refreshed: 2, Changed: 1, Failed: 1}, summary)
+	assert.Equal(t, []time.Duration{time.Second}, *sleeps)
+	assert.Equal(t, 1, locks.released)
+	repo.AssertExpectations(t)
+}
+
+func TestRefreshScheduler_SkipsWhenLocked(t *testing.T) {
+	repo := new(mockPersonRepo)
+	locks := &fakeLocks{held: true}
+	s, _ := newTestScheduler(repo, locks, func(ctx context.Context, ids []uuid.UUID) ([]models.EnrichmentDiff, []error) {
+		t.Fatal("refresh must not run without the lock")
+		return nil, nil
+	})
+
+	_, ran, err := s.RunOnce(context.Background())
+	assert.NoError(t, err)
+	assert.False(t, ran)
+	repo.AssertNotCalled(t, "GetStalePersonIDs", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRefreshScheduler_StopsOnCancellation(t *testing.T) {
+	repo := new(mockPersonRepo)
+	locks := &fakeLocks{}
+	ids := []uuid.UUID{uuid.New(), uuid.New()}
+	repo.On("GetStalePersonIDs", mock.Anything, mock.Anything, uuid.Nil, 2).Return(ids, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	s, _ := newTestScheduler(repo, locks, func(ctx context.Context, ids []uuid.UUID) ([]models.EnrichmentDiff, []error) {
+		calls++
+		cancel()
+		return make([]models.EnrichmentDiff, len(ids)), []error{ctx.Err(), ctx.Err()}
+	})
+
+	_, _, err := s.RunOnce(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, 1, locks.released)
+}
@@ -1,17 +1,142 @@
 package service
 
 import (
+	"fmt"
+
 	"github.com/adal4ik/people-enrichment-service/internal/config"
 	"github.com/adal4ik/people-enrichment-service/internal/repository"
 	"go.uber.org/zap"
 )
 
 type Service struct {
-	PersonService *PersonService
+	PersonService   *PersonService
+	ProviderService *ProviderService
+	// EnrichmentPool is nil unless enrichment runs in async mode.
+	EnrichmentPool *EnrichmentPool
+	// JobQueue is nil unless enrichment runs in queue mode.
+	JobQueue *JobQueue
+	// RefreshScheduler is nil when periodic re-enrichment is disabled.
+	RefreshScheduler *RefreshScheduler
+	// UsageTracker is nil when provider usage tracking is disabled.
+	UsageTracker *UsageTracker
 }
 
-func New(repo *repository.Repository, cfg config.Config, logger *zap.Logger) *Service {
-	return &Service{
-		PersonService: NewPersonService(repo.PersonRepository, cfg, logger),
+func New(repo *repository.Repository, cfg config.Config, logger *zap.Logger) (*Service, error) {
+	client, err := NewProviderHTTPClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build provider HTTP client: %w", err)
+	}
+	return NewWithHTTPClient(repo, cfg, client, logger)
+}
+
+// NewWithHTTPClient is New with the client used for outbound provider calls
+// supplied by the caller, e.g. a fake in tests.
+func NewWithHTTPClient(repo *repository.Repository, cfg config.Config, httpClient HTTPDoer, logger *zap.Logger) (*Service, error) {
+	var (
+		middlewares []EnricherMiddleware
+		cache       *ResultCache
+		cacheRepo   repository.CacheRepositoryInterface
+		breakers    *BreakerSet
+		limiters    *RateLimiterSet
+		coalescer   *Coalescer
+	)
+	if cfg.BreakerFailureThreshold > 0 {
+		breakers = NewBreakerSet(cfg.BreakerFailureThreshold, cfg.BreakerCooldown, logger)
+		middlewares = append(middlewares, WithCircuitBreaker(breakers))
+	}
+	// The breaker guards only real provider calls, and the persistent cache sits
+	// below the in-memory one so that in-memory hits never reach the database.
+	if cfg.PersistentCacheMaxAge > 0 {
+		cacheRepo = repo.CacheRepository
+		middlewares = append(middlewares, WithPersistentCache(cacheRepo, cfg.PersistentCacheMaxAge, logger))
+	}
+	if cfg.CacheMaxSize > 0 && cfg.CacheTTL > 0 {
+		cache = NewResultCache(cfg.CacheMaxSize, cfg.CacheTTL)
+		middlewares = append(middlewares, WithCache(cache))
+	}
+	// Concurrent misses of the same name share one lookup below this point.
+	if cfg.EnrichCoalesce {
+		coalescer = NewCoalescer()
+		middlewares = append(middlewares, WithCoalescing(coalescer))
+	}
+	// Rules look at the patronymic and surname, so they must sit above the
+	// caches, which are keyed by first name only.
+	if cfg.GenderRulesTieBreak > 0 {
+		middlewares = append(middlewares, WithGenderTieBreak(cfg.GenderRulesTieBreak, logger))
+	}
+
+	retryPolicy := RetryPolicy{
+		MaxAttempts: cfg.RetryMaxAttempts,
+		BaseDelay:   cfg.RetryBaseDelay,
+		MaxDelay:    cfg.RetryMaxDelay,
+	}
+	if cfg.RateLimitEnabled {
+		limiters = NewRateLimiterSet(cfg.RateLimitReserve, cfg.RateLimitMaxWait, logger)
+	}
+	var usage *UsageTracker
+	if cfg.UsageFlushInterval > 0 {
+		usage = NewUsageTracker(repo.UsageRepository, cfg.UsageFlushInterval, logger)
 	}
+	// Every retry attempt goes through the provider's rate limiter and is
+	// counted against its API key.
+	clients := func(provider string) HTTPDoer {
+		client := httpClient
+		key := ProviderAPIKey(cfg, provider)
+		if key != "" {
+			client = NewAPIKeyClient(client, cfg.ProviderAPIKeyParam, key)
+		}
+		if usage != nil {
+			client = usage.Client(provider, KeyID(key), client)
+		}
+		if limiters != nil {
+			client = NewRateLimitedClient(client, limiters.For(provider))
+		}
+		return NewRetryClient(client, retryPolicy, logger.With(zap.String("provider", provider)))
+	}
+
+	switch cfg.EnrichPolicy {
+	case PolicyStrict, PolicyBestEffort:
+	default:
+		return nil, fmt.Errorf("unknown enrichment policy %q", cfg.EnrichPolicy)
+	}
+	names, err := NewNameNormalizer(cfg.NameTransliteration)
+	if err != nil {
+		return nil, err
+	}
+
+	registry, err := NewRegistryFromConfig(cfg, clients, logger, middlewares...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build enrichment registry: %w", err)
+	}
+	personService := NewPersonService(repo.PersonRepository, registry, cfg, logger)
+
+	var (
+		pool  *EnrichmentPool
+		queue *JobQueue
+	)
+	switch cfg.EnrichMode {
+	case "sync":
+	case "async":
+		pool = NewEnrichmentPool(cfg.EnrichWorkers, cfg.EnrichQueueSize, personService.enrichPending, personService.abandonPending, logger)
+		personService.pool = pool
+	case "queue":
+		queue = NewJobQueue(repo.JobRepository, personService.enrichQueued, cfg, logger)
+		personService.pool = queue
+	default:
+		return nil, fmt.Errorf("unknown enrichment mode %q", cfg.EnrichMode)
+	}
+
+	var scheduler *RefreshScheduler
+	if cfg.RefreshInterval > 0 {
+		scheduler = NewRefreshScheduler(repo.PersonRepository, repo.LockRepository, personService.ReEnrichPersons, cfg, logger)
+	}
+
+	return &Service{
+		PersonService:    personService,
+		ProviderService:  NewProviderService(registry, cache, cacheRepo, breakers, limiters, usage, coalescer, names, logger),
+		EnrichmentPool:   pool,
+		JobQueue:         queue,
+		RefreshScheduler: scheduler,
+		UsageTracker:     usage,
+	}, nil
 }
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Transliteration standards for Cyrillic names.
+const (
+	// TranslitICAO follows ICAO Doc 9303, as used in machine-readable passports.
+	TranslitICAO = "icao"
+	// TranslitGOST follows GOST 7.79-2000 system B without its apostrophe marks,
+	// which no provider understands.
+	TranslitGOST = "gost"
+	// TranslitNone keeps Cyrillic names as they are.
+	TranslitNone = "none"
+)
+
+var icaoTable = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e", 'ж': "zh",
+	'з': "z", 'и': "i", 'й': "i", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+	'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts",
+	'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "ie", 'ы': "y", 'ь': "", 'э': "e", 'ю': "iu",
+	'я': "ia", 'і': "i", 'ї': "i", 'є': "ie", 'ґ': "g", 'ў': "u",
+}
+
+var gostTable = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "yo", 'ж': "zh",
+	'з': "z", 'и': "i", 'й': "j", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+	'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "x", 'ц': "cz",
+	'ч': "ch", 'ш': "sh", 'щ': "shh", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu",
+	'я': "ya", 'і': "i", 'ї': "yi", 'є': "ye", 'ґ': "g", 'ў': "u",
+}
+
+// NameNormalizer turns a name into the form sent to providers and stored for
+// searching: trimmed, with inner whitespace collapsed, Cyrillic transliterated
+// and lower-cased.
+type NameNormalizer struct {
+	standard string
+	table    map[rune]string
+}
+
+func NewNameNormalizer(standard string) (*NameNormalizer, error) {
+	n := &NameNormalizer{standard: standard}
+	switch standard {
+	case TranslitICAO:
+		n.table = icaoTable
+	case TranslitGOST:
+		n.table = gostTable
+	case TranslitNone, "":
+	default:
+		return nil, fmt.Errorf("unknown transliteration standard %q", standard)
+	}
+	return n, nil
+}
+
+// Normalize returns the normalized form of name. A nil normalizer only trims
+// and case-folds.
+func (n *NameNormalizer) Normalize(name string) string {
+	name = strings.ToLower(strings.Join(strings.Fields(name), " "))
+	if n == nil || n.table == nil || !isCyrillic(name) {
+		return name
+	}
+
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		latin, ok := n.table[r]
+		if !ok {
+			b.WriteRune(r)
+			continue
+		}
+		// GOST writes "ц" as "c" before the letters rendered as i, e, y and j.
+		if r == 'ц' && n.standard == TranslitGOST && i+1 < len(runes) {
+			if next := n.table[runes[i+1]]; next != "" && strings.ContainsRune("ieyj", rune(next[0])) {
+				latin = "c"
+			}
+		}
+		b.WriteString(latin)
+	}
+	return b.String()
+}
+
+// isCyrillic reports whether s contains any Cyrillic letter.
+func isCyrillic(s string) bool {
+	for _, r := range s {
+		if unicode.Is(unicode.Cyrillic, r) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/adal4ik/people-enrichment-service/internal/config"
+	"github.com/adal4ik/people-enrichment-service/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNameNormalizer_Normalize(t *testing.T) {
+	tests := []struct {
+		standard string
+		name     string
+		want     string
+	}{
+		{TranslitICAO, "  Дмитрий ", "dmitrii"},
+		{TranslitICAO, "Юлия", "iuliia"},
+		{TranslitICAO, "Алексей", "aleksei"},
+		{TranslitICAO, "Наталья", "natalia"},
+		{TranslitICAO, "Пётр", "petr"},
+		{TranslitICAO, "Анна  Мария", "anna mariia"},
+		{TranslitGOST, "Дмитрий", "dmitrij"},
+		{TranslitGOST, "Юлия", "yuliya"},
+		{TranslitGOST, "Пётр", "pyotr"},
+		{TranslitGOST, "Цветана", "czvetana"},
+		{TranslitGOST, "Лиция", "liciya"},
+		{TranslitNone, " Дмитрий", "дмитрий"},
+		{TranslitICAO, " ANDREA ", "andrea"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.standard+"/"+tt.name, func(t *testing.T) {
+			n, err := NewNameNormalizer(tt.standard)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, n.Normalize(tt.name))
+		})
+	}
+}
+
+func TestNameNormalizer_NilOnlyCaseFolds(t *testing.T) {
+	var n *NameNormalizer
+	assert.Equal(t, "дмитрий", n.Normalize(" Дмитрий "))
+}
+
+func TestNewNameNormalizer_UnknownStandard(t *testing.T) {
+	_, err := NewNameNormalizer("iso9")
+	assert.ErrorContains(t, err, "unknown transliteration standard")
+}
+
+func TestCreatePerson_QueriesTransliteratedName(t *testing.T) {
+	repo := new(mockPersonRepo)
+	gender := &countryEnricher{provider: "genderize", attr: AttributeGender, values: map[string]string{"": "male"}}
+	svc := newEnrichingService(repo, config.Config{NameTransliteration: TranslitICAO}, gender)
+	repo.On("CreatePerson", mock.Anything, mock.MatchedBy(func(p models.Person) bool {
+		return p.Name == "Дмитрий" && *p.NameNormalized == "dmitrii"
+	})).Return(nil)
+	repo.On("SaveEnrichments", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	_, err := svc.CreatePerson(context.Background(), models.Person{Name: "Дмитрий", Surname: "Иванов"})
+	assert.NoError(t, err)
+	assert.Equal(t, []Query{{Name: "dmitrii", Surname: "Иванов"}}, gender.queries)
+	repo.AssertExpectations(t)
+}
+
+func TestBackfillNormalizedNames(t *testing.T) {
+	repo := new(mockPersonRepo)
+	svc := newEnrichingService(repo, config.Config{NameTransliteration: TranslitICAO})
+	first, second := uuid.New(), uuid.New()
+
+	repo.On("GetUnnormalizedPersons", mock.Anything, backfillBatchSize).
+		Return([]models.Person{{ID: first, Name: " Дмитрий"}, {ID: second, Name: "John  Paul"}}, nil).Once()
+	repo.On("SetNameNormalized", mock.Anything, first, "dmitrii").Return(nil)
+	repo.On("SetNameNormalized", mock.Anything, second, "john paul").Return(nil)
+
+	n, err := svc.BackfillNormalizedNames(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	repo.AssertExpectations(t)
+}
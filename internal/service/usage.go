@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adal4ik/people-enrichment-service/internal/models"
+	"github.com/adal4ik/people-enrichment-service/internal/repository"
+	"go.uber.org/zap"
+)
+
+type usageKey struct {
+	provider string
+	keyID    string
+	day      time.Time
+}
+
+// UsageTracker counts the names looked up at each provider per API key and UTC
+// day, and periodically adds the counts to the persisted daily counters.
+// Providers bill per name, so a multi-name request counts once per name.
+type UsageTracker struct {
+	repo     repository.UsageRepositoryInterface
+	interval time.Duration
+	logger   *zap.Logger
+	now      func() time.Time
+
+	mu      sync.Mutex
+	pending map[usageKey]int64
+
+	wg     sync.WaitGroup
+	cancel context.CancelFunc
+}
+
+func NewUsageTracker(repo repository.UsageRepositoryInterface, interval time.Duration, logger *zap.Logger) *UsageTracker {
+	return &UsageTracker{
+		repo:     repo,
+		interval: interval,
+		logger:   logger,
+		now:      time.Now,
+		pending:  make(map[usageKey]int64),
+	}
+}
+
+// Client wraps client so that the names of every request provider answered
+// successfully are counted against keyID.
+func (t *UsageTracker) Client(provider, keyID string, client HTTPDoer) HTTPDoer {
+	return &usageClient{client: client, tracker: t, provider: provider, keyID: keyID}
+}
+
+func (t *UsageTracker) record(provider, keyID string, names int64) {
+	day := t.now().UTC().Truncate(24 * time.Hour)
+	t.mu.Lock()
+	t.pending[usageKey{provider, keyID, day}] += names
+	t.mu.Unlock()
+}
+
+// Start flushes the counters every interval until Shutdown is called.
+func (t *UsageTracker) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.cancel = cancel
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		ticker := time.NewTicker(t.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := t.Flush(ctx); err != nil && ctx.Err() == nil {
+					t.logger.Error("failed to save provider usage", zap.Error(err))
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Shutdown stops the periodic flush and saves what is still pending.
+func (t *UsageTracker) Shutdown(ctx context.Context) error {
+	if t.cancel != nil {
+		t.cancel()
+	}
+	t.wg.Wait()
+	return t.Flush(ctx)
+}
+
+// Flush adds the pending counts to the persisted counters. Counts that could
+// not be saved are kept for the next flush.
+func (t *UsageTracker) Flush(ctx context.Context) error {
+	t.mu.Lock()
+	pending := t.pending
+	t.pending = make(map[usageKey]int64)
+	t.mu.Unlock()
+
+	var errs []error
+	for key, requests := range pending {
+		usage := models.ProviderUsage{Provider: key.provider, KeyID: key.keyID, Day: key.day, Requests: requests}
+		if err := t.repo.AddUsage(ctx, usage); err != nil {
+			t.mu.Lock()
+			t.pending[key] += requests
+			t.mu.Unlock()
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Usage returns the counters of the last days days, today included, with the
+// requests not yet flushed added in.
+func (t *UsageTracker) Usage(ctx context.Context, days int) ([]models.ProviderUsage, error) {
+	since := t.now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 1-days)
+	persisted, err := t.repo.GetUsage(ctx, since)
+	if err != nil {
+		return nil, err
+	}
+
+	totals := make(map[usageKey]int64, len(persisted))
+	for _, u := range persisted {
+		totals[usageKey{u.Provider, u.KeyID, u.Day.UTC()}] += u.Requests
+	}
+	t.mu.Lock()
+	for key, requests := range t.pending {
+		if !key.day.Before(since) {
+			totals[key] += requests
+		}
+	}
+	t.mu.Unlock()
+
+	usage := make([]models.ProviderUsage, 0, len(totals))
+	for key, requests := range totals {
+		usage = append(usage, models.ProviderUsage{Provider: key.provider, KeyID: key.keyID, Day: key.day, Requests: requests})
+	}
+	slices.SortFunc(usage, func(a, b models.ProviderUsage) int {
+		if c := b.Day.Compare(a.Day); c != 0 {
+			return c
+		}
+		if c := strings.Compare(a.Provider, b.Provider); c != 0 {
+			return c
+		}
+		return strings.Compare(a.KeyID, b.KeyID)
+	})
+	return usage, nil
+}
+
+// usageClient counts the names of requests the provider answered with a 2xx
+// status. Rejected requests, e.g. 429s and failed attempts that were retried,
+// are not billed.
+type usageClient struct {
+	client   HTTPDoer
+	tracker  *UsageTracker
+	provider string
+	keyID    string
+}
+
+func (c *usageClient) Do(req *http.Request) (*http.Response, error) {
+	resp, err := c.client.Do(req)
+	if resp != nil && resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		c.tracker.record(c.provider, c.keyID, requestNames(req))
+	}
+	return resp, err
+}
+
+// requestNames is the number of names looked up by req: the name[] values of a
+// batch request, otherwise one.
+func requestNames(req *http.Request) int64 {
+	if names := req.URL.Query()["name[]"]; len(names) > 0 {
+		return int64(len(names))
+	}
+	return 1
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adal4ik/people-enrichment-service/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type mockUsageRepo struct {
+	mock.Mock
+}
+
+func (m *mockUsageRepo) AddUsage(ctx context.Context, usage models.ProviderUsage) error {
+	return m.Called(ctx, usage).Error(0)
+}
+
+func (m *mockUsageRepo) GetUsage(ctx context.Context, since time.Time) ([]models.ProviderUsage, error) {
+	args := m.Called(ctx, since)
+	return args.Get(0).([]models.ProviderUsage), args.Error(1)
+}
+
+// doerFunc adapts a function to HTTPDoer.
+type doerFunc func(req *http.Request) (*http.Response, error)
+
+func (f doerFunc) Do(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestUsageTracker_CountsBilledNames(t *testing.T) {
+	repo := new(mockUsageRepo)
+	tracker := NewUsageTracker(repo, time.Minute, zap.NewNop())
+	now := time.Date(2025, 6, 19, 15, 4, 5, 0, time.UTC)
+	tracker.now = func() time.Time { return now }
+
+	var (
+		status = http.StatusOK
+		fail   bool
+	)
+	client := tracker.Client("genderize", "1a2b3c4d", doerFunc(func(req *http.Request) (*http.Response, error) {
+		if fail {
+			return nil, errors.New("connection refused")
+		}
+		return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(""))}, nil
+	}))
+	single, err := http.NewRequest(http.MethodGet, "http://provider.test/?name=anna", nil)
+	require.NoError(t, err)
+	batch, err := http.NewRequest(http.MethodGet, "http://provider.test/?name[]=anna&name[]=ivan&name[]=zed", nil)
+	require.NoError(t, err)
+
+	client.Do(single)
+	client.Do(batch)
+	for _, status = range []int{http.StatusTooManyRequests, http.StatusBadGateway} {
+		client.Do(batch)
+	}
+	fail = true
+	client.Do(single)
+
+	day := time.Date(2025, 6, 19, 0, 0, 0, 0, time.UTC)
+	repo.On("AddUsage", mock.Anything, models.ProviderUsage{Provider: "genderize", KeyID: "1a2b3c4d", Day: day, Requests: 4}).Return(nil).Once()
+	require.NoError(t, tracker.Flush(context.Background()))
+	require.NoError(t, tracker.Flush(context.Background()), "nothing left to save")
+	repo.AssertExpectations(t)
+}
+
+func TestUsageTracker_KeepsCountsWhenSaveFails(t *testing.T) {
+	repo := new(mockUsageRepo)
+	tracker := NewUsageTracker(repo, time.Minute, zap.NewNop())
+	tracker.record("agify", "", 1)
+	tracker.record("agify", "", 1)
+
+	repo.On("AddUsage", mock.Anything, mock.Anything).Return(errors.New("db down")).Once()
+	assert.Error(t, tracker.Flush(context.Background()))
+
+	repo.On("AddUsage", mock.Anything, mock.MatchedBy(func(u models.ProviderUsage) bool { return u.Requests == 2 })).Return(nil).Once()
+	assert.NoError(t, tracker.Flush(context.Background()))
+	repo.AssertExpectations(t)
+}
+
+func TestUsageTracker_UsageIncludesPendingCounts(t *testing.T) {
+	repo := new(mockUsageRepo)
+	tracker := NewUsageTracker(repo, time.Minute, zap.NewNop())
+	now := time.Date(2025, 6, 19, 15, 0, 0, 0, time.UTC)
+	tracker.now = func() time.Time { return now }
+	today := time.Date(2025, 6, 19, 0, 0, 0, 0, time.UTC)
+	yesterday := today.AddDate(0, 0, -1)
+
+	repo.On("GetUsage", mock.Anything, yesterday).Return([]models.ProviderUsage{
+		{Provider: "genderize", Day: today, Requests: 10},
+		{Provider: "genderize", Day: yesterday, Requests: 40},
+	}, nil)
+	tracker.record("genderize", "", 1)
+	tracker.record("agify", "", 1)
+
+	usage, err := tracker.Usage(context.Background(), 2)
+	require.NoError(t, err)
+	assert.Equal(t, []models.ProviderUsage{
+		{Provider: "agify", Day: today, Requests: 1},
+		{Provider: "genderize", Day: today, Requests: 11},
+		{Provider: "genderize", Day: yesterday, Requests: 40},
+	}, usage)
+}
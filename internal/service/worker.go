@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+var ErrPoolClosed = errors.New("enrichment pool is shut down")
+
+// EnrichmentPool is a bounded pool of workers that enrich stored persons in
+// the background. Its queue lives in memory only: the persons whose
+// enrichment is cut short by a shutdown are handed to abandon instead.
+type EnrichmentPool struct {
+	mu      sync.RWMutex
+	closed  bool
+	jobs    chan uuid.UUID
+	workers int
+	handle  func(ctx context.Context, id uuid.UUID) error
+	abandon func(ctx context.Context, id uuid.UUID) error
+	logger  *zap.Logger
+
+	wg     sync.WaitGroup
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func NewEnrichmentPool(workers, queueSize int, handle, abandon func(ctx context.Context, id uuid.UUID) error, logger *zap.Logger) *EnrichmentPool {
+	return &EnrichmentPool{
+		jobs:    make(chan uuid.UUID, queueSize),
+		workers: max(workers, 1),
+		handle:  handle,
+		abandon: abandon,
+		logger:  logger,
+	}
+}
+
+// Start launches the workers. They stop when Shutdown is called.
+func (p *EnrichmentPool) Start() {
+	p.ctx, p.cancel = context.WithCancel(context.Background())
+	for i := 0; i < p.workers; i++ {
+		p.wg.Add(1)
+		go p.work(i)
+	}
+	p.logger.Info("enrichment workers started", zap.Int("workers", p.workers))
+}
+
+func (p *EnrichmentPool) work(worker int) {
+	defer p.wg.Done()
+	for id := range p.jobs {
+		if p.ctx.Err() != nil {
+			// The shutdown deadline passed before the job could start.
+			p.giveUp(id)
+			continue
+		}
+		if err := p.handle(p.ctx, id); err != nil {
+			if p.ctx.Err() != nil {
+				p.giveUp(id)
+				continue
+			}
+			p.logger.Error("background enrichment failed",
+				zap.Int("worker", worker),
+				zap.Any("id", id),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+// giveUp hands a person whose enrichment was interrupted by the shutdown to
+// abandon, so that it is not left pending.
+func (p *EnrichmentPool) giveUp(id uuid.UUID) {
+	if p.abandon == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), reportTimeout)
+	defer cancel()
+	if err := p.abandon(ctx, id); err != nil {
+		p.logger.Error("failed to give up background enrichment", zap.Any("id", id), zap.Error(err))
+	}
+}
+
+// Submit queues a person for enrichment, waiting for room in the queue.
+func (p *EnrichmentPool) Submit(ctx context.Context, id uuid.UUID) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		return ErrPoolClosed
+	}
+	select {
+	case p.jobs <- id:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Shutdown stops accepting work and waits for the queued jobs to drain. When
+// ctx expires, running jobs are cancelled and they and the jobs still queued
+// are handed to abandon.
+func (p *EnrichmentPool) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.jobs)
+	}
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		p.cancel()
+		p.logger.Info("enrichment workers drained")
+		return nil
+	case <-ctx.Done():
+		p.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
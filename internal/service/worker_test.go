@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/adal4ik/people-enrichment-service/internal/config"
+	"github.com/adal4ik/people-enrichment-service/internal/models"
+	"github.com/adal4ik/people-enrichment-service/utils"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func TestEnrichmentPool_DrainsOnShutdown(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		seen []uuid.UUID
+	)
+	pool := NewEnrichmentPool(2, 10, func(ctx context.Context, id uuid.UUID) error {
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		seen = append(seen, id)
+		mu.Unlock()
+		return nil
+	}, nil, zap.NewNop())
+	pool.Start()
+
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, pool.Submit(context.Background(), uuid.New()))
+	}
+	assert.NoError(t, pool.Shutdown(context.Background()))
+	assert.Len(t, seen, 5)
+	assert.ErrorIs(t, pool.Submit(context.Background(), uuid.New()), ErrPoolClosed)
+}
+
+func TestEnrichmentPool_ShutdownDeadlineAbandonsJobs(t *testing.T) {
+	started := make(chan struct{})
+	var abandoned []uuid.UUID
+	pool := NewEnrichmentPool(1, 1, func(ctx context.Context, id uuid.UUID) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	}, func(ctx context.Context, id uuid.UUID) error {
+		assert.NoError(t, ctx.Err())
+		abandoned = append(abandoned, id)
+		return nil
+	}, zap.NewNop())
+	pool.Start()
+	running, queued := uuid.New(), uuid.New()
+	assert.NoError(t, pool.Submit(context.Background(), running))
+	<-started
+	assert.NoError(t, pool.Submit(context.Background(), queued))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, pool.Shutdown(ctx), context.DeadlineExceeded)
+	assert.Equal(t, []uuid.UUID{running, queued}, abandoned)
+}
+
+func TestAbandonPending_MarksPendingPersonFailed(t *testing.T) {
+	repo := new(mockPersonRepo)
+	svc := newEnrichingService(repo, config.Config{})
+	pending, done := uuid.New(), uuid.New()
+
+	repo.On("GetPerson", mock.Anything, pending).
+		Return(models.Person{ID: pending, Name: "John", EnrichmentStatus: models.EnrichmentPending}, nil)
+	repo.On("GetPerson", mock.Anything, done).
+		Return(models.Person{ID: done, Name: "Anna", EnrichmentStatus: models.EnrichmentCompleted}, nil)
+	repo.On("UpdateEnrichment", mock.Anything, mock.MatchedBy(func(p models.Person) bool {
+		return p.ID == pending && p.EnrichmentStatus == models.EnrichmentFailed &&
+			*p.EnrichmentError == "enrichment interrupted by shutdown"
+	})).Return(nil).Once()
+
+	assert.NoError(t, svc.abandonPending(context.Background(), pending))
+	assert.NoError(t, svc.abandonPending(context.Background(), done))
+	repo.AssertExpectations(t)
+}
+
+func TestCreatePerson_AsyncStoresPending(t *testing.T) {
+	repo := new(mockPersonRepo)
+	svc := newEnrichingService(repo, config.Config{},
+		&stubEnricher{provider: "agify", attr: AttributeAge, value: "30"},
+	)
+	queued := make(chan uuid.UUID, 1)
+	pool := NewEnrichmentPool(1, 1, func(ctx context.Context, id uuid.UUID) error {
+		queued <- id
+		return nil
+	}, nil, zap.NewNop())
+	pool.Start()
+	defer pool.Shutdown(context.Background())
+	svc.pool = pool
+
+	id := uuid.New()
+	repo.On("CreatePerson", mock.Anything, mock.MatchedBy(func(p models.Person) bool {
+		return p.EnrichmentStatus == models.EnrichmentPending && p.Age == nil
+	})).Return(nil)
+
+	created, err := svc.CreatePerson(context.Background(), models.Person{ID: id, Name: "John"})
+	assert.NoError(t, err)
+	assert.Equal(t, models.EnrichmentPending, created.EnrichmentStatus)
+	assert.Equal(t, id, <-queued)
+	repo.AssertExpectations(t)
+}
+
+// failingQueue refuses every submission.
+type failingQueue struct{ err error }
+
+func (q failingQueue) Submit(ctx context.Context, id uuid.UUID) error { return q.err }
+
+func TestCreatePerson_RemovesPersonThatCannotBeQueued(t *testing.T) {
+	repo := new(mockPersonRepo)
+	svc := newEnrichingService(repo, config.Config{})
+	svc.pool = failingQueue{err: ErrPoolClosed}
+
+	id := uuid.New()
+	repo.On("CreatePerson", mock.Anything, mock.Anything).Return(nil)
+	repo.On("DeletePerson", mock.Anything, id).Return(nil)
+
+	created, err := svc.CreatePerson(context.Background(), models.Person{ID: id, Name: "John"})
+	assert.ErrorIs(t, err, utils.ErrEnrichmentNotQueued)
+	assert.ErrorIs(t, err, ErrPoolClosed)
+	assert.Equal(t, models.Person{}, created)
+	repo.AssertExpectations(t)
+}
+
+func TestEnrichPending_Completes(t *testing.T) {
+	repo := new(mockPersonRepo)
+	svc := newEnrichingService(repo, config.Config{},
+		&stubEnricher{provider: "agify", attr: AttributeAge, value: "30"},
+	)
+	id := uuid.New()
+
+	repo.On("GetPerson", mock.Anything, id).
+		Return(models.Person{ID: id, Name: "John", EnrichmentStatus: models.EnrichmentPending}, nil)
+	repo.On("UpdateEnrichment", mock.Anything, mock.MatchedBy(func(p models.Person) bool {
+		return p.EnrichmentStatus == models.EnrichmentCompleted && *p.Age == 30
+	})).Return(nil)
+	repo.On("SaveEnrichments", mock.Anything, id, mock.Anything).Return(nil)
+
+	assert.NoError(t, svc.enrichPending(context.Background(), id))
+	repo.AssertExpectations(t)
+}
+
+func TestEnrichPending_MarksFailed(t *testing.T) {
+	repo := new(mockPersonRepo)
+	svc := newEnrichingService(repo, config.Config{},
+		&stubEnricher{provider: "agify", attr: AttributeAge, err: errors.New("agify down")},
+	)
+	id := uuid.New()
+
+	repo.On("GetPerson", mock.Anything, id).
+		Return(models.Person{ID: id, Name: "John", EnrichmentStatus: models.EnrichmentPending}, nil)
+	repo.On("UpdateEnrichment", mock.Anything, mock.MatchedBy(func(p models.Person) bool {
+		return p.EnrichmentStatus == models.EnrichmentFailed && p.EnrichmentError != nil
+	})).Return(nil)
+
+	err := svc.enrichPending(context.Background(), id)
+	assert.ErrorContains(t, err, "agify down")
+	repo.AssertExpectations(t)
+}
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_person_enrichments_fetched_at;
+
+DROP TABLE IF EXISTS person_enrichments;
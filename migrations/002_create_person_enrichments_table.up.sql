@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS person_enrichments (
+    person_id UUID NOT NULL REFERENCES persons (id) ON DELETE CASCADE,
+    attribute TEXT NOT NULL,
+    provider TEXT NOT NULL,
+    value TEXT,
+    probability DOUBLE PRECISION,
+    sample_count INT,
+    evidence JSONB,
+    fetched_at TIMESTAMP NOT NULL DEFAULT now(),
+    PRIMARY KEY (person_id, attribute)
+);
+
+CREATE INDEX IF NOT EXISTS idx_person_enrichments_fetched_at ON person_enrichments (fetched_at);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_name_enrichment_cache_fetched_at;
+DROP INDEX IF EXISTS idx_name_enrichment_cache_name;
+
+DROP TABLE IF EXISTS name_enrichment_cache;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS name_enrichment_cache (
+    provider TEXT NOT NULL,
+    name TEXT NOT NULL,
+    attribute TEXT NOT NULL,
+    value TEXT,
+    probability DOUBLE PRECISION,
+    sample_count INT,
+    evidence JSONB,
+    fetched_at TIMESTAMP NOT NULL DEFAULT now(),
+    PRIMARY KEY (provider, name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_name_enrichment_cache_name ON name_enrichment_cache (name);
+CREATE INDEX IF NOT EXISTS idx_name_enrichment_cache_fetched_at ON name_enrichment_cache (fetched_at);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_persons_enrichment_status;
+
+ALTER TABLE persons
+    DROP COLUMN IF EXISTS enrichment_error,
+    DROP COLUMN IF EXISTS enrichment_status;
@@ -0,0 +1,6 @@
+ALTER TABLE persons
+    ADD COLUMN IF NOT EXISTS enrichment_status TEXT NOT NULL DEFAULT 'completed'
+        CHECK (enrichment_status IN ('pending', 'completed', 'failed')),
+    ADD COLUMN IF NOT EXISTS enrichment_error TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_persons_enrichment_status ON persons (enrichment_status);
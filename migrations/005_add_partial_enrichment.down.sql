@@ -0,0 +1,10 @@
+UPDATE persons SET enrichment_status = 'completed' WHERE enrichment_status = 'partial';
+
+ALTER TABLE persons
+    DROP CONSTRAINT IF EXISTS persons_enrichment_status_check;
+ALTER TABLE persons
+    ADD CONSTRAINT persons_enrichment_status_check
+        CHECK (enrichment_status IN ('pending', 'completed', 'failed'));
+
+ALTER TABLE person_enrichments
+    DROP COLUMN IF EXISTS error;
@@ -0,0 +1,8 @@
+ALTER TABLE person_enrichments
+    ADD COLUMN IF NOT EXISTS error TEXT;
+
+ALTER TABLE persons
+    DROP CONSTRAINT IF EXISTS persons_enrichment_status_check;
+ALTER TABLE persons
+    ADD CONSTRAINT persons_enrichment_status_check
+        CHECK (enrichment_status IN ('pending', 'completed', 'partial', 'failed'));
@@ -0,0 +1,10 @@
+DELETE FROM name_enrichment_cache WHERE country_id <> '';
+ALTER TABLE name_enrichment_cache
+    DROP CONSTRAINT IF EXISTS name_enrichment_cache_pkey;
+ALTER TABLE name_enrichment_cache
+    ADD PRIMARY KEY (provider, name);
+ALTER TABLE name_enrichment_cache
+    DROP COLUMN IF EXISTS country_id;
+
+ALTER TABLE persons
+    DROP COLUMN IF EXISTS country_hint;
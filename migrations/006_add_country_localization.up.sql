@@ -0,0 +1,9 @@
+ALTER TABLE persons
+    ADD COLUMN IF NOT EXISTS country_hint TEXT;
+
+ALTER TABLE name_enrichment_cache
+    ADD COLUMN IF NOT EXISTS country_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE name_enrichment_cache
+    DROP CONSTRAINT IF EXISTS name_enrichment_cache_pkey;
+ALTER TABLE name_enrichment_cache
+    ADD PRIMARY KEY (provider, name, country_id);
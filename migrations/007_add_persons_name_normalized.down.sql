@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_persons_name_normalized;
+
+ALTER TABLE persons
+    DROP COLUMN IF EXISTS name_normalized;
@@ -0,0 +1,10 @@
+ALTER TABLE persons
+    ADD COLUMN IF NOT EXISTS name_normalized TEXT;
+
+-- Names without Cyrillic letters normalize to their trimmed, case-folded form.
+-- The remaining names are transliterated by the service on startup.
+UPDATE persons
+SET name_normalized = lower(regexp_replace(trim(name), '\s+', ' ', 'g'))
+WHERE name_normalized IS NULL AND name !~ '[\u0400-\u052F\u1C80-\u1C8F\u2DE0-\u2DFF\uA640-\uA69F]';
+
+CREATE INDEX IF NOT EXISTS idx_persons_name_normalized ON persons (name_normalized);
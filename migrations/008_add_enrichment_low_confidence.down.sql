@@ -0,0 +1,2 @@
+ALTER TABLE person_enrichments
+    DROP COLUMN IF EXISTS low_confidence;
@@ -0,0 +1,2 @@
+ALTER TABLE person_enrichments
+    ADD COLUMN IF NOT EXISTS low_confidence BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS provider_usage (
+    provider TEXT NOT NULL,
+    key_id TEXT NOT NULL DEFAULT '',
+    day DATE NOT NULL,
+    requests BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (provider, key_id, day)
+);
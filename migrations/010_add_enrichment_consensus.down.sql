@@ -0,0 +1,3 @@
+ALTER TABLE person_enrichments
+    DROP COLUMN IF EXISTS sources,
+    DROP COLUMN IF EXISTS strategy;
@@ -0,0 +1,3 @@
+ALTER TABLE person_enrichments
+    ADD COLUMN IF NOT EXISTS strategy TEXT,
+    ADD COLUMN IF NOT EXISTS sources JSONB;
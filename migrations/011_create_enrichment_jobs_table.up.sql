@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS enrichment_jobs (
+    id BIGSERIAL PRIMARY KEY,
+    person_id UUID NOT NULL REFERENCES persons (id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'running', 'done', 'failed')),
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    run_at TIMESTAMP NOT NULL DEFAULT now(),
+    lease_id UUID,
+    locked_until TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    updated_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+-- At most one open job per person, so enqueueing twice is harmless.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_enrichment_jobs_open_person
+    ON enrichment_jobs (person_id) WHERE status IN ('pending', 'running');
+CREATE INDEX IF NOT EXISTS idx_enrichment_jobs_status_run_at ON enrichment_jobs (status, run_at);
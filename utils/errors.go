@@ -3,3 +3,15 @@ package utils
 import "errors"
 
 var ErrPersonNotFound = errors.New("person not found")
+
+var ErrNoProvider = errors.New("no enrichment provider configured")
+
+var ErrCircuitOpen = errors.New("provider circuit breaker is open")
+
+var ErrQuotaExhausted = errors.New("provider quota exhausted")
+
+var ErrEnrichmentNotQueued = errors.New("person could not be queued for enrichment")
+
+var ErrUnknownAttribute = errors.New("unknown enrichment attribute")
+
+var ErrJobLeaseLost = errors.New("enrichment job lease lost")
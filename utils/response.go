@@ -10,6 +10,18 @@ type APIResponse struct {
 	Message string `json:"message"`
 }
 
+// CreatedResponse is returned when a person is accepted for creation.
+type CreatedResponse struct {
+	Code             int    `json:"code"`
+	Message          string `json:"message"`
+	ID               string `json:"id"`
+	EnrichmentStatus string `json:"enrichment_status"`
+	// FailedAttributes lists the attributes a partially enriched person is missing.
+	FailedAttributes map[string]string `json:"failed_attributes,omitempty"`
+	// LowConfidenceAttributes lists the attributes left unknown as too uncertain.
+	LowConfidenceAttributes []string `json:"low_confidence_attributes,omitempty"`
+}
+
 type APIError struct {
 	Code     int    `json:"code"`
 	Message  string `json:"message"`
@@ -38,3 +50,14 @@ func (r *APIResponse) Send(w http.ResponseWriter) {
 	w.WriteHeader(r.Code)
 	w.Write(j)
 }
+
+func (r *CreatedResponse) Send(w http.ResponseWriter) {
+	j, err := json.MarshalIndent(r, "", "\t")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(r.Code)
+	w.Write(j)
+}